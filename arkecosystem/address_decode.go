@@ -16,8 +16,8 @@
 package arkecosystem
 
 import (
-	"github.com/blocktree/arkecosystem-adapter/arkecosystem_addrec"
-	"github.com/blocktree/go-owcdrivers/addressEncoder"
+	"fmt"
+	b58 "github.com/btcsuite/btcutil/base58"
 )
 
 type AddressDecoder struct {
@@ -33,17 +33,20 @@ func NewAddressDecoder(wm *WalletManager) *AddressDecoder {
 
 //PrivateKeyToWIF 私钥转WIF
 func (decoder *AddressDecoder) PrivateKeyToWIF(priv []byte, isTestnet bool) (string, error) {
-	wif := addressEncoder.AddressEncode(priv, addressEncoder.BTC_mainnetPrivateWIFCompressed)
-	return wif, nil
+	if len(priv) != 32 {
+		return "", fmt.Errorf("invalid private key length %d", len(priv))
+	}
+	privateKey := decoder.wm.Config.Crypto.PrivateKeyFromBytes(priv)
+	return privateKey.ToWif(), nil
 }
 
 //PublicKeyToAddress 公钥转地址
 func (decoder *AddressDecoder) PublicKeyToAddress(pub []byte, isTestnet bool) (string, error) {
-	address, err := arkecosystem_addrec.Default.AddressEncode(pub)
+	publicKey, err := decoder.wm.Config.Crypto.PublicKeyFromBytes(pub)
 	if err != nil {
 		return "", err
 	}
-	return address, nil
+	return publicKey.ToAddress(), nil
 }
 
 //RedeemScriptToAddress 多重签名赎回脚本转地址
@@ -53,9 +56,20 @@ func (decoder *AddressDecoder) RedeemScriptToAddress(pubs [][]byte, required uin
 
 //WIFToPrivateKey WIF转私钥
 func (decoder *AddressDecoder) WIFToPrivateKey(wif string, isTestnet bool) ([]byte, error) {
-	priv, err := addressEncoder.AddressDecode(wif, addressEncoder.BTC_mainnetPrivateWIFCompressed)
+	decoded, version, err := b58.CheckDecode(wif)
 	if err != nil {
 		return nil, err
 	}
-	return priv, err
+	network := decoder.wm.Config.Crypto.GetNetwork()
+	if version != network.Wif {
+		return nil, fmt.Errorf("wif version %d mismatch network wif %d", version, network.Wif)
+	}
+	//压缩公钥标识
+	if len(decoded) == 33 && decoded[32] == 0x01 {
+		decoded = decoded[:32]
+	}
+	if len(decoded) != 32 {
+		return nil, fmt.Errorf("invalid wif private key length %d", len(decoded))
+	}
+	return decoded, nil
 }
@@ -2,17 +2,70 @@ package arkecosystem
 
 import (
 	"encoding/hex"
-	"fmt"
 	"testing"
+
+	"github.com/blocktree/arkecosystem-adapter/sdk/crypto"
 )
 
 func TestAddressDecoder_PublicKeyToAddress(t *testing.T) {
-	pub, _ := hex.DecodeString("6e6490ba9ffa3ed276048e23c52f09a7622e02111124e9c770d1a6ac11a723c6")
-	decoder := AddressDecoder{}
-	addr, err := decoder.PublicKeyToAddress(pub, false)
+	pub, _ := hex.DecodeString("034151a3ec46b5670a682b0a63394f863587d1bc97483b1b6c70eb58e7f0aed192")
+	wm := NewWalletManager()
+	decoder := NewAddressDecoder(wm)
+
+	for _, network := range []*crypto.Network{crypto.NETWORKS_MAINNET, crypto.NETWORKS_DEVNET} {
+		wm.Config.Crypto.SetNetwork(network)
+		addr, err := decoder.PublicKeyToAddress(pub, false)
+		if err != nil {
+			t.Errorf("PublicKeyToAddress error: %v", err)
+			return
+		}
+		publicKey, _ := crypto.PublicKeyFromBytes(pub)
+		publicKey.Network = network
+		if addr != publicKey.ToAddress() {
+			t.Errorf("PublicKeyToAddress got %s, want %s", addr, publicKey.ToAddress())
+		}
+		t.Logf("version %d address: %s", network.Version, addr)
+	}
+}
+
+func TestAddressDecoder_WIF(t *testing.T) {
+	priv, _ := hex.DecodeString("d8839c2432bfd0a67ef10a804ba991eabba19f154a3d707917681d45822a5712")
+	wm := NewWalletManager()
+	decoder := NewAddressDecoder(wm)
+
+	wif, err := decoder.PrivateKeyToWIF(priv, false)
+	if err != nil {
+		t.Errorf("PrivateKeyToWIF error: %v", err)
+		return
+	}
+	got, err := decoder.WIFToPrivateKey(wif, false)
 	if err != nil {
-		t.Errorf("PublicKeyToAddress error: %v", err)
+		t.Errorf("WIFToPrivateKey error: %v", err)
 		return
 	}
-	fmt.Println(addr)
+	if hex.EncodeToString(got) != hex.EncodeToString(priv) {
+		t.Errorf("WIFToPrivateKey got %x, want %x", got, priv)
+	}
+
+	wm.Config.Crypto.SetNetwork(crypto.NETWORKS_TESTNET)
+	_, err = decoder.WIFToPrivateKey(wif, false)
+	if err == nil {
+		t.Errorf("WIFToPrivateKey should reject wif of another network")
+	}
+}
+
+func TestAddressDecoder_IsolatedNetwork(t *testing.T) {
+	pub, _ := hex.DecodeString("034151a3ec46b5670a682b0a63394f863587d1bc97483b1b6c70eb58e7f0aed192")
+	mainnet := NewWalletManager()
+	devnet := NewWalletManager()
+	devnet.Config.Crypto.SetNetwork(crypto.NETWORKS_DEVNET)
+
+	mainnetAddr, _ := NewAddressDecoder(mainnet).PublicKeyToAddress(pub, false)
+	devnetAddr, _ := NewAddressDecoder(devnet).PublicKeyToAddress(pub, false)
+	if mainnetAddr == devnetAddr {
+		t.Errorf("wallet managers share the same network, address: %s", mainnetAddr)
+	}
+	if crypto.GetNetwork().Version != crypto.NETWORKS_MAINNET.Version {
+		t.Errorf("default crypto network should not be changed by wallet manager")
+	}
 }
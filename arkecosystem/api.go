@@ -1,24 +1,461 @@
 package arkecosystem
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"github.com/blocktree/arkecosystem-adapter/sdk/client"
+	"github.com/blocktree/openwallet/v2/log"
+	"io/ioutil"
+	"net"
+	"net/http"
 	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
 	baseURLPath = "/api"
+
+	//默认节点健康检查间隔
+	defaultHealthCheckInterval = 30 * time.Second
+	//节点请求响应超时
+	nodeResponseTimeout = 30 * time.Second
+	//同步高度相差在此范围内的节点视为同一高度，按延迟优先
+	nodeHeightTolerance = 1
+	//自动发现节点的数量上限
+	maxDiscoveredNodes = 10
+	//自动发现的节点连续失败次数达到上限后移除
+	maxDiscoveredNodeFailures = 3
+	//节点API端口在peers列表中的标识
+	peerAPIPort = "@arkecosystem/core-api"
 )
 
+//ApiNode 节点及其健康状态
+type ApiNode struct {
+	//节点API地址，包含/api/路径
+	URL *url.URL
+	//直连该节点的客户端，不做故障转移
+	Client *client.Client
+	//是否通过peers自动发现
+	Discovered bool
+
+	Height    int64
+	Syncing   bool
+	Healthy   bool
+	Latency   time.Duration
+	Failures  int
+	LastError error
+	LastCheck time.Time
+}
+
 type Api struct {
+	//带故障转移的客户端，请求按健康排序依次尝试各节点
 	Client *client.Client
+	//是否通过Peers.List发现更多节点。发现的节点未经认证，只用于查询，
+	//排在配置的节点之后，不参与广播及区块交叉核对
+	DiscoverPeers bool
+
+	mu        sync.RWMutex
+	baseURL   *url.URL
+	nodes     []*ApiNode
+	transport http.RoundTripper
+	stop      chan struct{}
+	metrics   Metrics
 }
 
+//NewApi 创建节点API，baseUrl可以是逗号分隔的多个节点地址
 func NewApi(baseUrl string) *Api {
-	client2 := client.NewClient(nil)
-	URL, _ := url.Parse(baseUrl + baseURLPath + "/")
-	client2.BaseURL = URL
-	Api :=&Api{
-		Client: client2,
+	api := &Api{
+		transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: 10 * time.Second}).DialContext,
+			ResponseHeaderTimeout: nodeResponseTimeout,
+			IdleConnTimeout:       90 * time.Second,
+			MaxIdleConnsPerHost:   10,
+		},
+	}
+
+	for _, u := range strings.Split(baseUrl, ",") {
+		api.addNode(strings.TrimSpace(u), false)
+	}
+
+	client2 := client.NewClient(&http.Client{Transport: api})
+	if len(api.nodes) > 0 {
+		api.baseURL = api.nodes[0].URL
+		client2.BaseURL = api.baseURL
+	} else {
+		//未配置节点，保持原有的空地址行为
+		URL, _ := url.Parse(baseUrl + baseURLPath + "/")
+		client2.BaseURL = URL
+		api.baseURL = URL
+	}
+	api.Client = client2
+	return api
+}
+
+//addNode 添加节点，已存在的地址忽略
+func (api *Api) addNode(baseUrl string, discovered bool) bool {
+	if len(baseUrl) == 0 {
+		return false
+	}
+	URL, err := url.Parse(strings.TrimSuffix(baseUrl, "/") + baseURLPath + "/")
+	if err != nil || len(URL.Host) == 0 {
+		log.Warningf("invalid serverAPI [%s]", baseUrl)
+		return false
+	}
+	for _, node := range api.nodes {
+		if node.URL.String() == URL.String() {
+			return false
+		}
+	}
+	nodeClient := client.NewClient(&http.Client{Transport: api.transport})
+	nodeClient.BaseURL = URL
+	api.nodes = append(api.nodes, &ApiNode{
+		URL:        URL,
+		Client:     nodeClient,
+		Discovered: discovered,
+		//未检查前默认可用
+		Healthy: true,
+	})
+	return true
+}
+
+//configuredNodesKey 请求上下文标记，只发送到配置的节点
+type configuredNodesKey struct{}
+
+//WithConfiguredNodes 请求只发送到配置的节点，不使用通过peers发现的节点，用于广播等需要可信节点的请求
+func WithConfiguredNodes(ctx context.Context) context.Context {
+	return context.WithValue(ctx, configuredNodesKey{}, true)
+}
+
+//configuredNodes 过滤掉通过peers发现的节点
+func configuredNodes(nodes []*ApiNode) []*ApiNode {
+	configured := make([]*ApiNode, 0, len(nodes))
+	for _, node := range nodes {
+		if !node.Discovered {
+			configured = append(configured, node)
+		}
+	}
+	return configured
+}
+
+//Nodes 按健康状态排序的节点列表：健康节点在前，同步高度最高的节点按延迟排序，发现的节点排在配置的健康节点之后
+func (api *Api) Nodes() []*ApiNode {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+
+	nodes := make([]*ApiNode, len(api.nodes))
+	copy(nodes, api.nodes)
+
+	//最高高度以配置的节点为准，避免发现的节点以虚高的高度影响排序
+	var maxHeight int64
+	for _, node := range nodes {
+		if node.Healthy && !node.Discovered && node.Height > maxHeight {
+			maxHeight = node.Height
+		}
+	}
+	if maxHeight == 0 {
+		for _, node := range nodes {
+			if node.Healthy && node.Height > maxHeight {
+				maxHeight = node.Height
+			}
+		}
+	}
+	synced := func(node *ApiNode) bool {
+		return maxHeight-node.Height <= nodeHeightTolerance
+	}
+	tier := func(node *ApiNode) int {
+		if !node.Healthy {
+			return 4
+		}
+		level := 0
+		if node.Discovered {
+			level = 2
+		}
+		if !synced(node) {
+			level++
+		}
+		return level
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		if tier(a) != tier(b) {
+			return tier(a) < tier(b)
+		}
+		if a.Healthy && synced(a) {
+			return a.Latency < b.Latency
+		}
+		if a.Height != b.Height {
+			return a.Height > b.Height
+		}
+		return a.Failures < b.Failures
+	})
+	return nodes
+}
+
+//RoundTrip 将请求依次发送到排序后的节点，网络错误或节点5xx错误时切换到下一个节点
+func (api *Api) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+
+	relPath := strings.TrimPrefix(req.URL.Path, api.baseURL.Path)
+	nodes := api.Nodes()
+	if configuredOnly, _ := req.Context().Value(configuredNodesKey{}).(bool); configuredOnly {
+		nodes = configuredNodes(nodes)
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no serverAPI is configured")
+	}
+
+	var lastErr error
+	for i, node := range nodes {
+		target := *node.URL
+		target.Path = node.URL.Path + relPath
+		target.RawQuery = req.URL.RawQuery
+
+		nodeReq := req.Clone(req.Context())
+		nodeReq.URL = &target
+		nodeReq.Host = target.Host
+		if body != nil {
+			nodeReq.Body = ioutil.NopCloser(bytes.NewReader(body))
+			nodeReq.ContentLength = int64(len(body))
+		}
+
+		start := time.Now()
+		resp, err := api.transport.RoundTrip(nodeReq)
+		api.recordRequest(node, time.Since(start), err == nil && resp.StatusCode < http.StatusBadRequest)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			api.markSuccess(node, time.Since(start))
+			return resp, nil
+		}
+
+		if err == nil {
+			err = fmt.Errorf("node response status: %s", resp.Status)
+			//最后一个节点的错误响应直接返回给调用方
+			if i == len(nodes)-1 {
+				api.markFailure(node, err)
+				return resp, nil
+			}
+			resp.Body.Close()
+		}
+
+		if req.Context().Err() != nil {
+			return nil, req.Context().Err()
+		}
+
+		api.markFailure(node, err)
+		log.Warningf("serverAPI [%s] request failed, unexpected error: %v", node.URL.Host, err)
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+//SetMetrics 设置记录各节点请求次数、错误次数及耗时的指标，为nil时不记录
+func (api *Api) SetMetrics(metrics Metrics) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.metrics = metrics
+}
+
+//recordRequest 记录一次节点请求，4xx及5xx响应计为错误
+func (api *Api) recordRequest(node *ApiNode, latency time.Duration, ok bool) {
+	api.mu.RLock()
+	metrics := api.metrics
+	api.mu.RUnlock()
+	if metrics == nil {
+		return
+	}
+
+	result := "ok"
+	if !ok {
+		result = "error"
+	}
+	metrics.AddCounter(MetricApiRequests, map[string]string{"node": node.URL.Host, "result": result}, 1)
+	metrics.Observe(MetricApiLatency, map[string]string{"node": node.URL.Host}, latency.Seconds())
+}
+
+//markSuccess 请求成功后恢复节点健康状态（同步中的节点除外），延迟按移动平均更新
+func (api *Api) markSuccess(node *ApiNode, latency time.Duration) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	node.Failures = 0
+	node.LastError = nil
+	node.Healthy = !node.Syncing
+	if node.Latency == 0 {
+		node.Latency = latency
+	} else {
+		node.Latency = (node.Latency*3 + latency) / 4
+	}
+}
+
+func (api *Api) markFailure(node *ApiNode, err error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	node.Failures++
+	node.Healthy = false
+	node.LastError = err
+}
+
+//CheckHealth 通过Node.Syncing及Node.Status检查各节点的同步高度、同步状态及延迟，并按需发现更多节点
+func (api *Api) CheckHealth(ctx context.Context) {
+	api.mu.RLock()
+	nodes := make([]*ApiNode, len(api.nodes))
+	copy(nodes, api.nodes)
+	api.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(node *ApiNode) {
+			defer wg.Done()
+			api.checkNode(ctx, node)
+		}(node)
+	}
+	wg.Wait()
+
+	api.removeFailedNodes()
+
+	if api.DiscoverPeers {
+		api.discoverPeers(ctx)
+	}
+}
+
+//checkNode 检查单个节点
+func (api *Api) checkNode(ctx context.Context, node *ApiNode) {
+	start := time.Now()
+	syncing, _, err := node.Client.Node.Syncing(ctx)
+	latency := time.Since(start)
+	if err == nil && syncing == nil {
+		err = errEmptyNodeResponse
+	}
+	if err == nil {
+		var status *client.GetNodeStatus
+		status, _, err = node.Client.Node.Status(ctx)
+		if err == nil && status == nil {
+			err = errEmptyNodeResponse
+		}
+		if err == nil && !status.Data.Synced {
+			syncing.Data.Syncing = true
+		}
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	node.LastCheck = time.Now()
+	node.LastError = err
+	if err != nil {
+		node.Failures++
+		node.Healthy = false
+		return
+	}
+	node.Failures = 0
+	node.Height = syncing.Data.Height
+	node.Syncing = syncing.Data.Syncing
+	node.Latency = latency
+	node.Healthy = !node.Syncing && node.Height > 0
+}
+
+//removeFailedNodes 移除连续失败的自动发现节点
+func (api *Api) removeFailedNodes() {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	nodes := make([]*ApiNode, 0, len(api.nodes))
+	for _, node := range api.nodes {
+		if node.Discovered && node.Failures >= maxDiscoveredNodeFailures {
+			log.Infof("remove discovered serverAPI [%s]", node.URL.Host)
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	api.nodes = nodes
+}
+
+//discoverPeers 从最优节点的peers列表中发现开放API端口的节点
+func (api *Api) discoverPeers(ctx context.Context) {
+	nodes := api.Nodes()
+	if len(nodes) == 0 || !nodes[0].Healthy {
+		return
+	}
+
+	peers, _, err := nodes[0].Client.Peers.List(ctx, &client.Pagination{Page: 1, Limit: 100})
+	if err == nil && peers == nil {
+		err = errEmptyNodeResponse
+	}
+	if err != nil {
+		log.Warningf("discover peers from serverAPI [%s] failed, unexpected error: %v", nodes[0].URL.Host, err)
+		return
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	discovered := 0
+	for _, node := range api.nodes {
+		if node.Discovered {
+			discovered++
+		}
+	}
+	for _, peer := range peers.Data {
+		if discovered >= maxDiscoveredNodes {
+			break
+		}
+		port, ok := peer.Ports[peerAPIPort]
+		if !ok || port <= 0 {
+			continue
+		}
+		if api.addNode(fmt.Sprintf("http://%s:%d", peer.Ip, port), true) {
+			discovered++
+			log.Infof("discovered serverAPI [%s:%d]", peer.Ip, port)
+		}
+	}
+}
+
+//StartHealthCheck 定时检查节点健康状态
+func (api *Api) StartHealthCheck(ctx context.Context, interval time.Duration) {
+	api.StopHealthCheck()
+
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+	stop := make(chan struct{})
+	api.mu.Lock()
+	api.stop = stop
+	api.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				api.CheckHealth(ctx)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+//StopHealthCheck 停止定时健康检查
+func (api *Api) StopHealthCheck() {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if api.stop != nil {
+		close(api.stop)
+		api.stop = nil
 	}
-	return Api
 }
@@ -0,0 +1,142 @@
+package arkecosystem
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func testApiNodeServer(height int64, syncing bool, delay time.Duration, peers string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc(baseURLPath+"/node/syncing", func(writer http.ResponseWriter, request *http.Request) {
+		time.Sleep(delay)
+		fmt.Fprintf(writer, `{"data": {"syncing": %t, "blocks": 0, "height": %d, "id": "dummy"}}`, syncing, height)
+	})
+	mux.HandleFunc(baseURLPath+"/node/status", func(writer http.ResponseWriter, request *http.Request) {
+		fmt.Fprintf(writer, `{"data": {"synced": %t, "now": %d, "blocksCount": 0}}`, !syncing, height)
+	})
+	mux.HandleFunc(baseURLPath+"/peers", func(writer http.ResponseWriter, request *http.Request) {
+		fmt.Fprintf(writer, `{"meta": {"count": 1}, "data": [%s]}`, peers)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestApi_Failover(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		http.Error(writer, "bad gateway", http.StatusBadGateway)
+	}))
+	defer down.Close()
+	up := testApiNodeServer(100, false, 0, "")
+	defer up.Close()
+
+	api := NewApi(down.URL + "," + up.URL)
+	result, _, err := api.Client.Node.Syncing(context.Background())
+	if err != nil {
+		t.Errorf("Node.Syncing error: %v", err)
+		return
+	}
+	if result.Data.Height != 100 {
+		t.Errorf("Node.Syncing height got %d, want 100", result.Data.Height)
+	}
+	if nodes := api.Nodes(); nodes[0].URL.Host != hostOf(up.URL) {
+		t.Errorf("failed node should be ranked last, got %s", nodes[0].URL.Host)
+	}
+}
+
+func TestApi_MarkSuccess(t *testing.T) {
+	api := NewApi("http://127.0.0.1:1")
+	node := api.Nodes()[0]
+
+	//请求失败后再次成功，恢复健康状态
+	api.markFailure(node, fmt.Errorf("timeout"))
+	api.markSuccess(node, 100*time.Millisecond)
+	if !node.Healthy || node.Failures != 0 || node.LastError != nil || node.Latency != 100*time.Millisecond {
+		t.Errorf("recovered node got %+v", node)
+	}
+
+	api.markSuccess(node, 500*time.Millisecond)
+	if node.Latency != 200*time.Millisecond {
+		t.Errorf("moving average latency got %v, want 200ms", node.Latency)
+	}
+
+	//同步中的节点保持不健康
+	node.Syncing = true
+	api.markFailure(node, fmt.Errorf("timeout"))
+	api.markSuccess(node, 100*time.Millisecond)
+	if node.Healthy {
+		t.Errorf("syncing node should stay unhealthy")
+	}
+}
+
+func TestApi_CheckHealth(t *testing.T) {
+	syncing := testApiNodeServer(200, true, 0, "")
+	defer syncing.Close()
+	slow := testApiNodeServer(120, false, 50*time.Millisecond, "")
+	defer slow.Close()
+	fast := testApiNodeServer(119, false, 0, "")
+	defer fast.Close()
+	behind := testApiNodeServer(80, false, 0, "")
+	defer behind.Close()
+
+	api := NewApi(syncing.URL + "," + behind.URL + "," + slow.URL + "," + fast.URL)
+	api.CheckHealth(context.Background())
+
+	want := []string{fast.URL, slow.URL, behind.URL, syncing.URL}
+	for i, node := range api.Nodes() {
+		if node.URL.Host != hostOf(want[i]) {
+			t.Errorf("node %d got %s (height %d), want %s", i, node.URL.Host, node.Height, hostOf(want[i]))
+		}
+	}
+}
+
+func TestApi_CheckHealthEmptyResponse(t *testing.T) {
+	empty := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	defer empty.Close()
+
+	//空响应体视为节点异常
+	api := NewApi(empty.URL)
+	api.CheckHealth(context.Background())
+	if node := api.Nodes()[0]; node.Healthy || node.LastError != errEmptyNodeResponse {
+		t.Errorf("node with empty response got healthy %t, error %v", node.Healthy, node.LastError)
+	}
+}
+
+func TestApi_DiscoverPeers(t *testing.T) {
+	//发现的节点更快且高度更高
+	peer := testApiNodeServer(150, false, 0, "")
+	defer peer.Close()
+	peerURL, _ := url.Parse(peer.URL)
+
+	seed := testApiNodeServer(100, false, 20*time.Millisecond,
+		fmt.Sprintf(`{"ip": "%s", "port": 4002, "ports": {"%s": %s}, "height": 100, "latency": 300}`,
+			peerURL.Hostname(), peerAPIPort, peerURL.Port()))
+
+	api := NewApi(seed.URL)
+	api.DiscoverPeers = true
+	api.CheckHealth(context.Background())
+	api.CheckHealth(context.Background())
+
+	//发现的节点不可信，排在配置的节点之后
+	nodes := api.Nodes()
+	if len(nodes) != 2 || nodes[0].Discovered || !nodes[1].Discovered {
+		t.Fatalf("discover peers got %d nodes", len(nodes))
+	}
+
+	//配置的节点不可用时，查询可使用发现的节点，广播等请求只使用配置的节点
+	seed.Close()
+	if _, _, err := api.Client.Node.Syncing(context.Background()); err != nil {
+		t.Errorf("query through discovered node error: %v", err)
+	}
+	if _, _, err := api.Client.Node.Syncing(WithConfiguredNodes(context.Background())); err == nil {
+		t.Errorf("configured nodes only request should not use discovered node")
+	}
+}
+
+func hostOf(rawurl string) string {
+	u, _ := url.Parse(rawurl)
+	return u.Host
+}
@@ -4,6 +4,8 @@ import (
 	"github.com/astaxie/beego/config"
 	"github.com/blocktree/openwallet/v2/log"
 	"github.com/blocktree/openwallet/v2/openwallet"
+	"path/filepath"
+	"time"
 )
 
 //CurveType 曲线类型
@@ -13,7 +15,7 @@ func (wm *WalletManager) CurveType() uint32 {
 
 //FullName 币种全名
 func (wm *WalletManager) FullName() string {
-	return "arkecosystem"
+	return wm.Config.FullName
 }
 
 //Symbol 币种标识
@@ -23,7 +25,7 @@ func (wm *WalletManager) Symbol() string {
 
 //Decimal 小数位精度
 func (wm *WalletManager) Decimal() int32 {
-	return 8
+	return wm.Config.Decimals
 }
 
 //BalanceModelType 余额模型类别
@@ -52,14 +54,111 @@ func (wm *WalletManager) GetBlockScanner() openwallet.BlockScanner {
 func (wm *WalletManager) LoadAssetsConfig(c config.Configer) error {
 
 	wm.Config.ServerAPI = c.String("serverAPI")
-	wm.Config.FixFees = "0.1"
-	wm.Config.NetworkID = c.String("networkID")
+	wm.Config.DiscoverPeers, _ = c.Bool("discoverPeers")
+	if healthCheckInterval, _ := c.Int64("healthCheckInterval"); healthCheckInterval > 0 {
+		wm.Config.HealthCheckInterval = time.Duration(healthCheckInterval) * time.Second
+	}
+	wm.Config.FixFees = c.String("fixFees")
+	if nonceExpireTime, _ := c.Int64("nonceExpireTime"); nonceExpireTime > 0 {
+		wm.Config.NonceExpireTime = time.Duration(nonceExpireTime) * time.Second
+	}
+	wm.NonceManager.ExpireTime = wm.Config.NonceExpireTime
+	if scanConcurrency, _ := c.Int("scanConcurrency"); scanConcurrency > 0 {
+		wm.Config.ScanConcurrency = scanConcurrency
+	}
+	if confirmations, _ := c.Int64("confirmations"); confirmations > 0 {
+		wm.Config.Confirmations = uint64(confirmations)
+	}
+	if maxReorgDepth, _ := c.Int64("maxReorgDepth"); maxReorgDepth > 0 {
+		wm.Config.MaxReorgDepth = uint64(maxReorgDepth)
+	}
+	wm.Config.BlockQuorum, _ = c.Int("blockQuorum")
+	wm.Config.BlockQuorumNodes, _ = c.Int("blockQuorumNodes")
+	wm.Config.VerifyBlocks, _ = c.Bool("verifyBlocks")
+	wm.Config.WebhookListen = c.String("webhookListen")
+	if webhookPath := c.String("webhookPath"); len(webhookPath) > 0 {
+		wm.Config.WebhookPath = webhookPath
+	}
+	wm.Config.WebhookToken = c.String("webhookToken")
+	if unscanMaxAttempts, err := c.Int("unscanMaxAttempts"); err == nil && unscanMaxAttempts >= 0 {
+		wm.Config.UnscanMaxAttempts = unscanMaxAttempts
+	}
+	if unscanRetryInterval, _ := c.Int64("unscanRetryInterval"); unscanRetryInterval > 0 {
+		wm.Config.UnscanRetryInterval = time.Duration(unscanRetryInterval) * time.Second
+	}
+	if unscanMaxRetryInterval, _ := c.Int64("unscanMaxRetryInterval"); unscanMaxRetryInterval > 0 {
+		wm.Config.UnscanMaxRetryInterval = time.Duration(unscanMaxRetryInterval) * time.Second
+	}
+	wm.Config.LocalBlockStore, _ = c.Bool("localBlockStore")
+	if localBlockRetention, err := c.Int64("localBlockRetention"); err == nil && localBlockRetention >= 0 {
+		wm.Config.LocalBlockRetention = uint64(localBlockRetention)
+	}
+	wm.Config.MetricsListen = c.String("metricsListen")
+	if scanStallTimeout, _ := c.Int64("scanStallTimeout"); scanStallTimeout > 0 {
+		wm.Config.ScanStallTimeout = time.Duration(scanStallTimeout) * time.Second
+	}
+	wm.Config.MempoolScan, _ = c.Bool("mempoolScan")
+	if mempoolScanInterval, _ := c.Int64("mempoolScanInterval"); mempoolScanInterval > 0 {
+		wm.Config.MempoolScanInterval = time.Duration(mempoolScanInterval) * time.Second
+	}
+
+	err := wm.Config.loadNetwork(c)
+	if err != nil {
+		return err
+	}
+
+	if wm.Api != nil {
+		wm.Api.StopHealthCheck()
+	}
 	wm.Api = NewApi(wm.Config.ServerAPI)
+	wm.Api.DiscoverPeers = wm.Config.DiscoverPeers
+	wm.Api.SetMetrics(wm.Blockscanner.Metrics())
+
+	//从节点加载链参数
+	if len(wm.Config.ServerAPI) > 0 {
+		wm.Api.CheckHealth(wm.Context)
+		wm.Api.StartHealthCheck(wm.Context, wm.Config.HealthCheckInterval)
+
+		err = wm.LoadNodeConfiguration()
+		if err != nil {
+			return err
+		}
+	}
+
+	//交易池扫描默认关闭
+	wm.Blockscanner.StopMempoolWatcher()
+	if wm.Config.MempoolScan {
+		wm.Blockscanner.StartMempoolWatcher(wm.Config.MempoolScanInterval)
+	}
+
+	//webhook接收服务默认关闭
+	wm.Blockscanner.StopWebhookReceiver()
+	if len(wm.Config.WebhookListen) > 0 {
+		err = wm.Blockscanner.StartWebhookReceiver(wm.Config.WebhookListen, wm.Config.WebhookPath, wm.Config.WebhookToken)
+		if err != nil {
+			return err
+		}
+	}
+
+	//指标及健康检查服务默认关闭
+	wm.Blockscanner.StopMetricsServer()
+	if len(wm.Config.MetricsListen) > 0 {
+		err = wm.Blockscanner.StartMetricsServer(wm.Config.MetricsListen)
+		if err != nil {
+			return err
+		}
+	}
 
 	//数据文件夹
 	wm.Config.DataDir = c.String("dataDir")
 
 	wm.Config.makeDataDir()
+
+	//本地区块库始终保存已通知的交易ID用于分叉回滚，完整区块默认不保存
+	err = wm.Blockscanner.openBlockStore(filepath.Join(wm.Config.dbPath, localBlockStoreFileName), wm.Config.LocalBlockRetention, wm.Config.LocalBlockStore)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
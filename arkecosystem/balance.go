@@ -0,0 +1,209 @@
+package arkecosystem
+
+import (
+	"fmt"
+	"github.com/blocktree/arkecosystem-adapter/sdk/client"
+	"github.com/blocktree/openwallet/v2/common"
+	"github.com/blocktree/openwallet/v2/openwallet"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	//Wallets.Search单次查询的地址数量上限
+	balanceSearchBatchSize = 50
+	//并行查询余额的批次数
+	balanceQueryConcurrency = 5
+)
+
+//AddressBalance 地址余额查询结果
+type AddressBalance struct {
+	Address string
+	//Balance、ConfirmBalance为链上可用余额，UnconfirmBalance扣除了交易池中转出的金额及手续费
+	Balance *openwallet.Balance
+	//HTLC锁定中的金额，不包含在Balance中
+	LockedBalance string
+	//查询失败的错误
+	Err error
+}
+
+//BalanceQueryError 部分地址余额查询失败，Errors记录每个失败地址的错误
+type BalanceQueryError struct {
+	Errors map[string]error
+}
+
+func (e *BalanceQueryError) Error() string {
+	addresses := make([]string, 0, len(e.Errors))
+	for address := range e.Errors {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	reasons := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		reasons = append(reasons, fmt.Sprintf("%s: %v", address, e.Errors[address]))
+	}
+	return fmt.Sprintf("get balance of %d addresses failed: %s", len(e.Errors), strings.Join(reasons, "; "))
+}
+
+//GetAddressBalances 批量查询地址余额，按Wallets.Search分批并行查询，结果顺序与addresses一致
+func (bs *ARKBlockScanner) GetAddressBalances(addresses []string) []*AddressBalance {
+
+	results := make([]*AddressBalance, len(addresses))
+	wallets := make(map[string]*client.Wallet)
+	errs := make(map[string]error)
+	var mu sync.Mutex
+
+	tokens := make(chan struct{}, balanceQueryConcurrency)
+	var wg sync.WaitGroup
+	for start := 0; start < len(addresses); start += balanceSearchBatchSize {
+		end := start + balanceSearchBatchSize
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+
+		wg.Add(1)
+		tokens <- struct{}{}
+		go func(batch []string) {
+			defer func() {
+				<-tokens
+				wg.Done()
+			}()
+			batchWallets, batchErrs := bs.getWallets(batch)
+			mu.Lock()
+			defer mu.Unlock()
+			for address, wallet := range batchWallets {
+				wallets[address] = wallet
+			}
+			for address, err := range batchErrs {
+				errs[address] = err
+			}
+		}(addresses[start:end])
+	}
+	wg.Wait()
+
+	//交易池中的转出金额
+	pending, err := bs.getPendingOutgoing()
+	if err != nil {
+		bs.wm.Log.Std.Warning("block scanner can not get pool transactions, unconfirmed balance is not deducted; unexpected error: %v", err)
+		pending = make(map[string]*big.Int)
+	}
+
+	for i, address := range addresses {
+		result := &AddressBalance{Address: address}
+		results[i] = result
+		if err := errs[address]; err != nil {
+			result.Err = err
+			continue
+		}
+
+		//链上没有记录的地址余额为0
+		balance, locked := big.NewInt(0), big.NewInt(0)
+		if wallet := wallets[address]; wallet != nil {
+			balance.SetUint64(wallet.Balance)
+			if wallet.Attributes != nil && wallet.Attributes.Htlc != nil {
+				locked.SetUint64(wallet.Attributes.Htlc.LockedBalance)
+			}
+		}
+
+		unconfirmed := new(big.Int).Set(balance)
+		if outgoing := pending[address]; outgoing != nil {
+			unconfirmed.Sub(unconfirmed, outgoing)
+		}
+		if unconfirmed.Sign() < 0 {
+			unconfirmed.SetInt64(0)
+		}
+
+		decimals := bs.wm.Decimal()
+		result.Balance = &openwallet.Balance{
+			Symbol:           bs.wm.Symbol(),
+			Address:          address,
+			Balance:          common.BigIntToDecimals(balance, decimals).String(),
+			ConfirmBalance:   common.BigIntToDecimals(balance, decimals).String(),
+			UnconfirmBalance: common.BigIntToDecimals(unconfirmed, decimals).String(),
+		}
+		result.LockedBalance = common.BigIntToDecimals(locked, decimals).String()
+	}
+
+	return results
+}
+
+//getWallets 通过Wallets.Search查询一批地址，节点不支持批量查询时逐个查询
+func (bs *ARKBlockScanner) getWallets(addresses []string) (map[string]*client.Wallet, map[string]error) {
+
+	wallets := make(map[string]*client.Wallet)
+	errs := make(map[string]error)
+
+	query := &client.Pagination{Page: 1, Limit: len(addresses)}
+	body := &client.WalletsSearchRequest{Addresses: addresses}
+	result, resp, err := bs.wm.Api.Client.Wallets.Search(bs.wm.Context, query, body)
+	err = checkNodeResponse(resp, err)
+	if err == nil && result != nil {
+		for i := range result.Data {
+			wallet := result.Data[i]
+			wallets[wallet.Address] = &wallet
+		}
+		return wallets, errs
+	}
+
+	bs.wm.Log.Std.Warning("block scanner can not search wallets, query one by one; unexpected error: %v", err)
+	for _, address := range addresses {
+		result, resp, err := bs.wm.Api.Client.Wallets.Get(bs.wm.Context, address)
+		if err == nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+			//未使用过的地址节点返回404，与批量查询一致视为余额为0
+			continue
+		}
+		err = checkNodeResponse(resp, err)
+		if err == nil && result == nil {
+			err = errEmptyNodeResponse
+		}
+		if err != nil {
+			errs[address] = err
+			continue
+		}
+		if result.Data.Address == address {
+			wallets[address] = &result.Data
+		}
+	}
+	return wallets, errs
+}
+
+//errEmptyNodeResponse 节点返回空响应体时SendRequest不报错且结果为nil，需视为查询失败
+var errEmptyNodeResponse = fmt.Errorf("node returned an empty response")
+
+//checkNodeResponse 节点返回错误状态码时SendRequest不报错，需视为查询失败
+func checkNodeResponse(resp *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	if resp != nil && resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("node response status: %s", resp.Status)
+	}
+	return nil
+}
+
+//getPendingOutgoing 交易池中各发送地址转出的金额及手续费
+func (bs *ARKBlockScanner) getPendingOutgoing() (map[string]*big.Int, error) {
+
+	txs, err := bs.wm.listPoolTransactions()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make(map[string]*big.Int)
+	for i := range txs {
+		trans := &txs[i]
+		outgoing := pending[trans.Sender]
+		if outgoing == nil {
+			outgoing = big.NewInt(0)
+			pending[trans.Sender] = outgoing
+		}
+		outgoing.Add(outgoing, new(big.Int).SetUint64(trans.Fee))
+		for _, payment := range transactionPayments(trans) {
+			outgoing.Add(outgoing, new(big.Int).SetUint64(payment.Amount))
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,136 @@
+package arkecosystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const (
+	testBalanceAddress  = "AJWRd23HNEhPLkK1ymMnwnDBX2a7QBZqff"
+	testEmptyAddress    = "AUexKjGtgsSpVzPLs6jNMM6vJ6znEVTQWK"
+	testNotFoundAddress = "AThM5PNSKdU9pu1ydqQnzRWVeNCGr8HKof"
+	testUnusedAddress   = "AJbmGnDAoMZR8G1TnsRRgDJMkAWDhmBaK2"
+)
+
+//testBalanceServer searchFailed为true时模拟节点不支持Wallets.Search
+func testBalanceServer(searchFailed bool, searches *int32) *httptest.Server {
+	wallet := fmt.Sprintf(`{"address": "%s", "balance": "1000000000", "attributes": {"htlc": {"lockedBalance": "200000000"}}}`, testBalanceAddress)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(baseURLPath+"/wallets/search", func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(searches, 1)
+		if searchFailed {
+			http.Error(writer, `{"error": "Not Found"}`, http.StatusNotFound)
+			return
+		}
+		var body struct {
+			Addresses []string `json:"addresses"`
+		}
+		json.NewDecoder(request.Body).Decode(&body)
+		data := make([]string, 0)
+		for _, address := range body.Addresses {
+			if address == testBalanceAddress {
+				data = append(data, wallet)
+			}
+		}
+		fmt.Fprintf(writer, `{"meta": {"count": %d, "pageCount": 1, "totalCount": %d}, "data": [%s]}`,
+			len(data), len(data), strings.Join(data, ","))
+	})
+	mux.HandleFunc(baseURLPath+"/wallets/", func(writer http.ResponseWriter, request *http.Request) {
+		switch strings.TrimPrefix(request.URL.Path, baseURLPath+"/wallets/") {
+		case testBalanceAddress:
+			fmt.Fprintf(writer, `{"data": %s}`, wallet)
+		case testEmptyAddress:
+			fmt.Fprintf(writer, `{"data": {"address": "%s", "balance": "0"}}`, testEmptyAddress)
+		case testUnusedAddress:
+			http.Error(writer, `{"statusCode": 404, "error": "Not Found", "message": "Wallet not found"}`, http.StatusNotFound)
+		default:
+			http.Error(writer, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc(baseURLPath+"/transactions/unconfirmed", func(writer http.ResponseWriter, request *http.Request) {
+		fmt.Fprintf(writer,
+			`{
+			  "meta": {"count": 2, "pageCount": 1, "totalCount": 2},
+			  "data": [
+			    {"id": "a", "type": 0, "typeGroup": 1, "sender": "%s", "recipient": "%s", "amount": "100000000", "fee": "10000000"},
+			    {"id": "b", "type": 0, "typeGroup": 1, "sender": "%s", "recipient": "%s", "amount": "50000000", "fee": "10000000"}
+			  ]
+			}`, testBalanceAddress, testEmptyAddress, testEmptyAddress, testBalanceAddress)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestARKBlockScanner_GetAddressBalances(t *testing.T) {
+	var searches int32
+	server := testBalanceServer(false, &searches)
+	defer server.Close()
+
+	bs, _, _, closeServer := newTestScanner(newTestChain(1, 0))
+	defer closeServer()
+	bs.wm.Api = NewApi(server.URL)
+
+	addresses := make([]string, 0)
+	for i := 0; i < balanceSearchBatchSize*2; i++ {
+		addresses = append(addresses, testEmptyAddress)
+	}
+	addresses = append(addresses, testBalanceAddress)
+
+	results := bs.GetAddressBalances(addresses)
+	if len(results) != len(addresses) {
+		t.Fatalf("got %d balances, want %d", len(results), len(addresses))
+	}
+	if n := atomic.LoadInt32(&searches); n != 3 {
+		t.Errorf("searched %d batches, want 3", n)
+	}
+
+	result := results[len(results)-1]
+	if result.Err != nil || result.Address != testBalanceAddress {
+		t.Fatalf("balance of %s got %s, %v", testBalanceAddress, result.Address, result.Err)
+	}
+	if result.Balance.Balance != "10" || result.Balance.ConfirmBalance != "10" ||
+		result.Balance.UnconfirmBalance != "8.9" || result.LockedBalance != "2" {
+		t.Errorf("balance got %+v, locked %s", result.Balance, result.LockedBalance)
+	}
+
+	//不在链上的地址余额为0，转出后余额不足时未确认余额不为负
+	empty := results[0]
+	if empty.Err != nil || empty.Balance.Balance != "0" || empty.Balance.UnconfirmBalance != "0" {
+		t.Errorf("empty balance got %+v, %v", empty.Balance, empty.Err)
+	}
+}
+
+func TestARKBlockScanner_GetBalanceByAddressFallback(t *testing.T) {
+	var searches int32
+	server := testBalanceServer(true, &searches)
+	defer server.Close()
+
+	bs, _, _, closeServer := newTestScanner(newTestChain(1, 0))
+	defer closeServer()
+	bs.wm.Api = NewApi(server.URL)
+
+	balances, err := bs.GetBalanceByAddress(testBalanceAddress, testNotFoundAddress, testEmptyAddress, testUnusedAddress)
+	if len(balances) != 3 {
+		t.Fatalf("got %d balances, want 3", len(balances))
+	}
+	if balances[0].Address != testBalanceAddress || balances[0].UnconfirmBalance != "8.9" {
+		t.Errorf("balance got %+v", balances[0])
+	}
+	//未使用过的地址与批量查询一致，余额为0
+	if balances[2].Address != testUnusedAddress || balances[2].Balance != "0" || balances[2].ConfirmBalance != "0" {
+		t.Errorf("unused address balance got %+v", balances[2])
+	}
+
+	queryErr, ok := err.(*BalanceQueryError)
+	if !ok {
+		t.Fatalf("got error %v, want *BalanceQueryError", err)
+	}
+	if len(queryErr.Errors) != 1 || queryErr.Errors[testNotFoundAddress] == nil {
+		t.Errorf("got errors %v, want error of %s", queryErr.Errors, testNotFoundAddress)
+	}
+}
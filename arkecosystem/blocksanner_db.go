@@ -56,9 +56,13 @@ func (bs *ARKBlockScanner) SaveLocalBlock(blockHeader *client.Block) error {
 	return bs.BlockchainDAI.SaveLocalBlockHead(header)
 }
 
-//GetLocalBlock 获取本地区块数据
+//GetLocalBlock 获取本地区块数据，开启本地区块库时返回完整区块
 func (bs *ARKBlockScanner) GetLocalBlock(height uint64) (*client.Block, error) {
 
+	if stored := bs.getStoredBlock(height); stored != nil {
+		return &stored.Block, nil
+	}
+
 	if bs.BlockchainDAI == nil {
 		return nil, fmt.Errorf("Blockchain DAI is not setup ")
 	}
@@ -69,9 +73,11 @@ func (bs *ARKBlockScanner) GetLocalBlock(height uint64) (*client.Block, error) {
 	}
 
 	block := &client.Block{
-		Id:     header.Hash,
-		Height: int64(header.Height),
+		Id:       header.Hash,
+		Height:   int64(header.Height),
+		Previous: header.Previousblockhash,
 	}
+	block.Timestamp.Unix = int32(header.Time)
 
 	return block, nil
 }
@@ -96,6 +102,16 @@ func (bs *ARKBlockScanner) DeleteUnscanRecord(height uint64) error {
 	return bs.BlockchainDAI.DeleteUnscanRecordByHeight(uint64(height), bs.wm.Symbol())
 }
 
+//DeleteUnscanRecordByID 删除指定的未扫记录
+func (bs *ARKBlockScanner) DeleteUnscanRecordByID(id string) error {
+
+	if bs.BlockchainDAI == nil {
+		return fmt.Errorf("Blockchain DAI is not setup ")
+	}
+
+	return bs.BlockchainDAI.DeleteUnscanRecordByID(id, bs.wm.Symbol())
+}
+
 func (bs *ARKBlockScanner) GetUnscanRecords() ([]*openwallet.UnscanRecord, error) {
 
 	if bs.BlockchainDAI == nil {
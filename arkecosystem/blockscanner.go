@@ -2,13 +2,15 @@ package arkecosystem
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/blocktree/arkecosystem-adapter/sdk/client"
 	"github.com/blocktree/openwallet/v2/common"
 	"github.com/blocktree/openwallet/v2/log"
 	"github.com/blocktree/openwallet/v2/openwallet"
 	"github.com/pkg/errors"
-	"math/big"
+	"sort"
+	"sync"
 	"time"
 )
 
@@ -16,17 +18,38 @@ const (
 	blockchainBucket = "blockchain" // blockchain dataset
 	//periodOfTask      = 5 * time.Second // task interval
 	maxExtractingSize = 0 // thread count
-	successTxType     = 0
+	//默认并行获取及提取的区块数
+	defaultScanConcurrency = 3
+	//区块交易分页大小
+	blockTransactionsPageSize = 100
+	successTxType             = 0
 )
 
 //AEBlockScanner LSK block scanner
 type ARKBlockScanner struct {
 	*openwallet.BlockScannerBase
 
-	CurrentBlockHeight   uint64         //当前区块高度
-	extractingCH         chan struct{}  //扫描工作令牌
-	wm                   *WalletManager //钱包管理者
-	RescanLastBlockCount uint64         //重扫上N个区块数量
+	CurrentBlockHeight   uint64             //当前区块高度
+	wm                   *WalletManager     //钱包管理者
+	RescanLastBlockCount uint64             //重扫上N个区块数量
+	reportedTxs          *reportedTxCache   //近期区块已通知的交易
+	MemoScanTargetFunc   MemoScanTargetFunc //按地址及备注查找扫描目标，为空时只按地址查找
+	mempool              *mempoolWatcher    //交易池扫描器
+	chainHeight          uint64             //最近获取的节点最新高度，用于计算确认数
+	chainHeightMu        sync.RWMutex
+	haltErr              error //本地校验失败导致扫描停止的错误
+	haltMu               sync.RWMutex
+	healthEvents         *healthEventLog   //近期的扫描器健康事件
+	webhook              *webhookReceiver  //webhook接收服务
+	scanMu               sync.Mutex        //定时扫描与webhook触发的扫描互斥
+	unscanRetries        *unscanRetryStore //未扫记录的重试状态
+	blockStore           *localBlockStore  //本地区块库
+	rescanJob            *RescanJob        //后台重扫任务
+	rescanMu             sync.Mutex
+	metrics              Metrics //扫描器及节点API的指标
+	metricsMu            sync.RWMutex
+	metricsServer        *metricsServer //指标及健康检查服务
+	scanStats            *scanStats     //扫描进度的时间记录
 }
 
 //ExtractResult extract result
@@ -37,11 +60,13 @@ type ExtractTxResult struct {
 
 //ExtractResult extract result
 type ExtractResult struct {
-	extractData []*ExtractTxResult
-	BlockHash   string
-	BlockHeight uint64
-	BlockTime   int64
-	Success     bool
+	extractData  []*ExtractTxResult
+	failedTxs    map[string]error     //提取失败的交易，按交易记录未扫记录
+	transactions []client.Transaction //区块的全部交易，保存到本地区块库
+	BlockHash    string
+	BlockHeight  uint64
+	BlockTime    int64
+	Success      bool
 }
 
 //SaveResult result
@@ -57,10 +82,18 @@ func NewARKBlockScanner(wm *WalletManager) *ARKBlockScanner {
 		BlockScannerBase: openwallet.NewBlockScannerBase(),
 	}
 
-	bs.extractingCH = make(chan struct{}, 3)
 	bs.wm = wm
 
 	bs.RescanLastBlockCount = maxExtractingSize
+	bs.reportedTxs = newReportedTxCache()
+	bs.mempool = newMempoolWatcher(&bs)
+	bs.healthEvents = &healthEventLog{}
+	bs.webhook = &webhookReceiver{}
+	bs.unscanRetries = &unscanRetryStore{}
+	bs.blockStore = &localBlockStore{}
+	bs.metrics = NewMemoryMetrics()
+	bs.metricsServer = &metricsServer{}
+	bs.scanStats = newScanStats()
 
 	// set task
 	bs.SetTask(bs.ScanBlockTask)
@@ -72,6 +105,9 @@ func NewARKBlockScanner(wm *WalletManager) *ARKBlockScanner {
 func (bs *ARKBlockScanner) getCurrentBlock() (*client.Block, error) {
 	query := &client.Pagination{Limit: 1}
 	result, _, err := bs.wm.Api.Client.Blocks.List(context.Background(), query)
+	if err == nil && result == nil {
+		err = errEmptyNodeResponse
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -79,9 +115,90 @@ func (bs *ARKBlockScanner) getCurrentBlock() (*client.Block, error) {
 		return nil, errors.New("can't found the block")
 	}
 	block := result.Data[0]
+	bs.setChainHeight(uint64(block.Height))
+	bs.recordNodeHeight(uint64(block.Height))
 	return &block, nil
 }
 
+//setChainHeight 记录节点最新高度
+func (bs *ARKBlockScanner) setChainHeight(height uint64) {
+	bs.chainHeightMu.Lock()
+	defer bs.chainHeightMu.Unlock()
+	if height > bs.chainHeight {
+		bs.chainHeight = height
+	}
+}
+
+//transactionConfirm 根据节点最新高度计算区块的确认数，未知高度时使用节点返回的确认数
+func (bs *ARKBlockScanner) transactionConfirm(trans *client.Transaction) int64 {
+	bs.chainHeightMu.RLock()
+	chainHeight := bs.chainHeight
+	bs.chainHeightMu.RUnlock()
+
+	blockHeight := uint64(trans.BlockHeight)
+	if blockHeight == 0 || chainHeight < blockHeight {
+		return int64(trans.Confirmations)
+	}
+	return int64(chainHeight - blockHeight + 1)
+}
+
+//confirmedHeight 达到配置确认数的最大区块高度
+func (bs *ARKBlockScanner) confirmedHeight(maxHeight uint64) uint64 {
+	confirmations := bs.wm.Config.Confirmations
+	if confirmations <= 1 {
+		return maxHeight
+	}
+	if maxHeight < confirmations {
+		return 0
+	}
+	return maxHeight - confirmations + 1
+}
+
+//getBlockTransactions 分页获取区块的全部交易，获取数量与区块交易数不一致时返回错误
+func (bs *ARKBlockScanner) getBlockTransactions(block *client.Block) ([]client.Transaction, error) {
+
+	transactionList := make([]client.Transaction, 0, block.Transactions)
+	if block.Transactions == 0 {
+		return transactionList, nil
+	}
+
+	//本地区块库中已保存同一区块
+	if stored := bs.getStoredBlock(uint64(block.Height)); stored != nil && stored.BlockID == block.Id &&
+		uint32(len(stored.Transactions)) == block.Transactions {
+		return append(transactionList, stored.Transactions...), nil
+	}
+
+	exist := make(map[string]bool)
+	for page := 1; uint32(len(transactionList)) < block.Transactions; page++ {
+		query := &client.PaginationBlock{Limit: blockTransactionsPageSize, Page: page, BlockId: block.Id}
+		trans, _, err := bs.wm.Api.Client.Transactions.ListByBlockId(context.Background(), query)
+		if err != nil {
+			return nil, err
+		}
+		if trans == nil || len(trans.Data) == 0 {
+			break
+		}
+		for _, tx := range trans.Data {
+			//翻页期间节点数据变化可能导致重复
+			if exist[tx.Id] {
+				continue
+			}
+			exist[tx.Id] = true
+			transactionList = append(transactionList, tx)
+		}
+		if trans.Meta.PageCount > 0 && uint32(page) >= trans.Meta.PageCount {
+			break
+		}
+	}
+
+	if uint32(len(transactionList)) != block.Transactions {
+		return nil, fmt.Errorf("block [%s] transactions count mismatch, expected %d, got %d",
+			block.Id, block.Transactions, len(transactionList))
+	}
+
+	return transactionList, nil
+}
+
 //GetBlockHeight 获取区块链高度
 func (bs *ARKBlockScanner) GetGlobalMaxBlockHeight() uint64 {
 
@@ -95,9 +212,21 @@ func (bs *ARKBlockScanner) GetGlobalMaxBlockHeight() uint64 {
 }
 
 func (bs *ARKBlockScanner) getBlockByHeight(height uint64) (*client.Block, error) {
+	//多节点交叉核对区块
+	if bs.quorumEnabled() {
+		return bs.getQuorumBlockByHeight(height)
+	}
+	return listBlockByHeight(bs.wm.Api.Client, height)
+}
+
+//listBlockByHeight 通过指定客户端查询高度对应的区块
+func listBlockByHeight(c *client.Client, height uint64) (*client.Block, error) {
 	query := &client.PaginationHeight{Limit: 1, Height: int(height), Page: 1}
 
-	result, _, err := bs.wm.Api.Client.Blocks.ListByHeight(context.Background(), query)
+	result, _, err := c.Blocks.ListByHeight(context.Background(), query)
+	if err == nil && result == nil {
+		err = errEmptyNodeResponse
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -150,42 +279,22 @@ func (bs *ARKBlockScanner) GetCurrentBlockHeader() (*openwallet.BlockHeader, err
 	return &openwallet.BlockHeader{Height: uint64(block.Height), Hash: block.Id}, nil
 }
 
-//GetBalanceByAddress 查询地址余额
+//GetBalanceByAddress 查询地址余额，部分地址查询失败时返回成功的余额及*BalanceQueryError
 func (bs *ARKBlockScanner) GetBalanceByAddress(address ...string) ([]*openwallet.Balance, error) {
 
-	addrBalanceArr := make([]*openwallet.Balance, 0)
-	for _, a := range address {
-		//query := &client.Pagination{Limit: 1,Page:1,}
-		//req := &client.WalletsSearchRequest{
-		//	Address: a,
-		//}
-		addressWallet, _, err := bs.wm.Api.Client.Wallets.Get(context.Background(), a)
-		if err == nil {
-
-			wallet := addressWallet.Data
-			//balance, err := common.StringValueToBigInt(string(wallet.Balance), 10)
-			//if err != nil {
-			//	continue
-			//}
-
-			balance := big.NewInt(int64(wallet.Balance))
-			b := common.BigIntToDecimals(balance, bs.wm.Decimal())
-			ub := common.BigIntToDecimals(balance, bs.wm.Decimal())
-			obj := &openwallet.Balance{
-				Symbol:           bs.wm.Symbol(),
-				Address:          a,
-				Balance:          ub.String(),
-				UnconfirmBalance: ub.String(),
-				ConfirmBalance:   b.String(),
-			}
-
-			//log.Warn("address:",a,",ubalance:",ub.String(),"confirmBlance:",b.String())
-			addrBalanceArr = append(addrBalanceArr, obj)
-			//return nil, err
+	addrBalanceArr := make([]*openwallet.Balance, 0, len(address))
+	errs := make(map[string]error)
+	for _, result := range bs.GetAddressBalances(address) {
+		if result.Err != nil {
+			errs[result.Address] = result.Err
+			continue
 		}
-
+		addrBalanceArr = append(addrBalanceArr, result.Balance)
 	}
 
+	if len(errs) > 0 {
+		return addrBalanceArr, &BalanceQueryError{Errors: errs}
+	}
 	return addrBalanceArr, nil
 }
 
@@ -222,41 +331,6 @@ func (bs *ARKBlockScanner) newBlockNotify(block *client.Block, isFork bool) {
 	bs.NewBlockNotify(header)
 }
 
-//extractRuntime 提取运行时
-func (bs *ARKBlockScanner) extractRuntime(producer chan ExtractResult, worker chan ExtractResult, quit chan struct{}) {
-
-	var (
-		values = make([]ExtractResult, 0)
-	)
-
-	for {
-
-		var activeWorker chan<- ExtractResult
-		var activeValue ExtractResult
-
-		//当数据队列有数据时，释放顶部，传输给消费者
-		if len(values) > 0 {
-			activeWorker = worker
-			activeValue = values[0]
-
-		}
-
-		select {
-
-		//生成者不断生成数据，插入到数据队列尾部
-		case pa := <-producer:
-			values = append(values, pa)
-		case <-quit:
-			//退出
-			return
-		case activeWorker <- activeValue:
-			//wm.Log.Std.Info("Get %d", len(activeValue))
-			values = values[1:]
-		}
-	}
-
-}
-
 //ExtractTransaction 提取交易单
 func (bs *ARKBlockScanner) ExtractTransaction(block *client.Block, scanTargetFunc openwallet.BlockScanTargetFunc) (ExtractResult, error) {
 
@@ -264,31 +338,33 @@ func (bs *ARKBlockScanner) ExtractTransaction(block *client.Block, scanTargetFun
 		Success:     true,
 		BlockHeight: uint64(block.Height),
 		extractData: make([]*ExtractTxResult, 0),
+		failedTxs:   make(map[string]error),
 	}
-	transactionList := make([]client.Transaction, 0)
-
-	pageSize := 100
-
-	query := &client.PaginationBlock{Limit: pageSize, Page: 1, BlockId: block.Id}
-
-	trans, _, err := bs.wm.Api.Client.Transactions.ListByBlockId(context.Background(), query)
-
+	transactionList, err := bs.getBlockTransactions(block)
 	if err != nil {
+		result.Success = false
 		log.Errorf("cant find the transaction by height %d ,err : %s", block.Height, err.Error())
 		return result, err
 	}
-	if trans.Data == nil || len(trans.Data) == 0 {
-		return result, nil
-	}
+	result.transactions = transactionList
 
-	transactionList = append(transactionList, trans.Data...)
+	//本地校验区块及交易，防止被篡改的节点数据入账
+	if bs.wm.Config.VerifyBlocks {
+		err = bs.verifyBlock(block, transactionList)
+		if err != nil {
+			result.Success = false
+			return result, err
+		}
+	}
 
 	if len(transactionList) != 0 {
 		for _, v := range transactionList {
 			v.BlockHeight = block.Height
 			resultTx, err := bs.changeTrans(&v, scanTargetFunc)
 			if err != nil {
-				bs.wm.Log.Std.Error("trans ID: %d, save unscan record failed. unexpected error: %v", v.Id, err.Error())
+				//交易提取失败则按交易记录未扫，避免漏账，不影响区块内其他交易
+				bs.wm.Log.Std.Error("trans ID: %s, extract failed. unexpected error: %v", v.Id, err.Error())
+				result.failedTxs[v.Id] = err
 				continue
 			}
 			result.extractData = append(result.extractData, &resultTx)
@@ -328,14 +404,45 @@ func (bs *ARKBlockScanner) changeTrans(trans *client.Transaction, scanTargetFunc
 	//	return resultTx, err
 	//}
 	//feeDec :=
-	amount := common.IntToDecimals(int64(v.Amount), bs.wm.Decimal()).String()
+	action := transactionAction(v)
+	memo, memoHex := transactionMemo(v)
+	payments := transactionPayments(v)
+	totalAmount := uint64(0)
+	for _, payment := range payments {
+		totalAmount += payment.Amount
+	}
+	//入账的收款，HTLC锁定的金额在领取前不入账，领取或退回时按锁定交易入账
+	credits := payments
+	fromAddress := v.Sender
+	switch action {
+	case TxActionHtlcLock:
+		credits = []*client.MultiPaymentAsset{}
+	case TxActionHtlcClaim, TxActionHtlcRefund:
+		lockTx, err := bs.getLockTransaction(v)
+		if err != nil {
+			return resultTx, err
+		}
+		credits = htlcUnlockPayments(action, lockTx)
+		fromAddress = lockTx.Sender
+	}
+	txAmount := totalAmount
+	to := make([]string, 0, len(payments))
+	for _, payment := range payments {
+		to = append(to, payment.RecipientId+":"+common.IntToDecimals(int64(payment.Amount), bs.wm.Decimal()).String())
+	}
+	if len(payments) == 0 {
+		for _, payment := range credits {
+			txAmount += payment.Amount
+			to = append(to, payment.RecipientId+":"+common.IntToDecimals(int64(payment.Amount), bs.wm.Decimal()).String())
+		}
+	}
+	amount := common.IntToDecimals(int64(totalAmount), bs.wm.Decimal()).String()
 	fees := common.IntToDecimals(int64(v.Fee), bs.wm.Decimal()).String()
-	from := v.Sender
-	to := v.Recipient
+	from := []string{fromAddress + ":" + common.IntToDecimals(int64(txAmount), bs.wm.Decimal()).String()}
 
 	sourceKey, ok := scanTargetFunc(
 		openwallet.ScanTarget{
-			Address:          from,
+			Address:          v.Sender,
 			BalanceModelType: openwallet.BalanceModelTypeAddress,
 			Symbol:bs.wm.Symbol(),
 
@@ -350,6 +457,12 @@ func (bs *ARKBlockScanner) changeTrans(trans *client.Transaction, scanTargetFunc
 			IsContract: false,
 		}
 		input.Index = 0
+		input.IsMemo = len(memoHex) > 0
+		input.Memo = memo
+		//TxInput没有扩展参数，非UTF-8的备注以hex记录，转出记录同样保留备注标签
+		if input.IsMemo && len(memo) == 0 {
+			input.Memo = memoHex
+		}
 		input.TxType = uint64(v.Type)
 		input.Sid = openwallet.GenTxInputSID(txID, bs.wm.Symbol(), "", uint64(0))
 		//input.CreateAt = createAt
@@ -362,33 +475,53 @@ func (bs *ARKBlockScanner) changeTrans(trans *client.Transaction, scanTargetFunc
 			resultTx.extractData[sourceKey] = ed
 		}
 
-		ed.TxInputs = append(ed.TxInputs, &input)
+		//不转移金额的交易只记录手续费
+		if totalAmount > 0 {
+			ed.TxInputs = append(ed.TxInputs, &input)
+		}
 
-		//手续费也作为一个输出
+		//手续费也作为一个输入，使用独立的序号
 		tmp := *&input
 		feeCharge := &tmp
 		feeCharge.Amount = fees
+		feeCharge.Index = 1
+		feeCharge.Sid = openwallet.GenTxInputSID(txID, bs.wm.Symbol(), "", uint64(1))
 		ed.TxInputs = append(ed.TxInputs, feeCharge)
 	}
 
-	sourceKey2, ok2 := scanTargetFunc(
-		openwallet.ScanTarget{
-			Address:          v.Recipient,
-			BalanceModelType: openwallet.BalanceModelTypeAddress,
-			Symbol:bs.wm.Symbol(),
-		})
-	if ok2 {
+	//HTLC锁定交易的收款人只记录交易，不产生输出
+	if action == TxActionHtlcLock {
+		for _, payment := range payments {
+			lockKey, ok := bs.scanRecipient(scanTargetFunc, payment.RecipientId, memo)
+			if ok && resultTx.extractData[lockKey] == nil {
+				resultTx.extractData[lockKey] = openwallet.NewBlockExtractData()
+			}
+		}
+	}
+
+	//多笔转账的每个收款人作为一个输出，分别匹配扫描目标
+	for i, payment := range credits {
+		sourceKey2, ok2 := bs.scanRecipient(scanTargetFunc, payment.RecipientId, memo)
+		if !ok2 {
+			continue
+		}
 		output := openwallet.TxOutPut{}
 		output.TxID = txID
-		output.Address = to
-		output.Amount = amount
+		output.Address = payment.RecipientId
+		output.Amount = common.IntToDecimals(int64(payment.Amount), bs.wm.Decimal()).String()
 		output.Coin = openwallet.Coin{
 			Symbol:     bs.wm.Symbol(),
 			IsContract: false,
 		}
-		output.Index = 0
+		output.Index = uint64(i)
+		output.IsMemo = len(memoHex) > 0
+		output.Memo = memo
+		if output.IsMemo {
+			output.SetExtParam("vendorField", memo)
+			output.SetExtParam("vendorFieldHex", memoHex)
+		}
 		output.TxType = uint64(v.Type)
-		output.Sid = openwallet.GenTxOutPutSID(txID, bs.wm.Symbol(), "", 0)
+		output.Sid = openwallet.GenTxOutPutSID(txID, bs.wm.Symbol(), "", uint64(i))
 		output.CreateAt = createAt
 		output.BlockHeight = uint64(v.BlockHeight)
 		//output.BlockHash = string(trx.BlockHash)
@@ -406,9 +539,9 @@ func (bs *ARKBlockScanner) changeTrans(trans *client.Transaction, scanTargetFunc
 		status := "1"
 		reason := ""
 		tx := &openwallet.Transaction{
-			From:   []string{from + ":" + amount},
-			To:     []string{to + ":" + amount},
-			Amount: amount,
+			From:   from,
+			To:     to,
+			Amount: common.IntToDecimals(int64(txAmount), bs.wm.Decimal()).String(),
 			Fees:   fees,
 			Coin: openwallet.Coin{
 				Symbol:     bs.wm.Symbol(),
@@ -422,11 +555,16 @@ func (bs *ARKBlockScanner) changeTrans(trans *client.Transaction, scanTargetFunc
 			Status:      status,
 			Reason:      reason,
 			TxType:      uint64(v.Type),
-			Confirm:     int64(trans.Confirmations),
+			TxAction:    action,
+			IsMemo:      len(memoHex) > 0,
+			Memo:        memo,
+			Confirm:     bs.transactionConfirm(trans),
 			//SubmitTime:  int64(block.Time),
 			//ConfirmTime: int64(trans.Timestamp.Unix),
 			ConfirmTime: int64(trans.Timestamp.Unix),
 		}
+		extParam, _ := json.Marshal(transactionExtParam(v, action))
+		tx.ExtParam = string(extParam)
 		wxID := openwallet.GenTransactionWxID(tx)
 		tx.WxID = wxID
 		extractData.Transaction = tx
@@ -439,9 +577,10 @@ func (bs *ARKBlockScanner) changeTrans(trans *client.Transaction, scanTargetFunc
 	return resultTx, nil
 }
 
-//newExtractDataNotify 发送通知
+//newExtractDataNotify 发送通知，通知失败的交易记录为未扫记录并返回*ExtractNotifyError
 func (bs *ARKBlockScanner) newExtractDataNotify(height uint64, extractTxResult []*ExtractTxResult) error {
 
+	failed := make([]string, 0)
 	for o, _ := range bs.Observers {
 		for _, txResult := range extractTxResult {
 			for key, data := range txResult.extractData {
@@ -453,141 +592,197 @@ func (bs *ARKBlockScanner) newExtractDataNotify(height uint64, extractTxResult [
 						txID = data.Transaction.TxID
 					}
 					//记录未扫区块
-					unscanRecord := NewUnscanRecord(height, txID, "ExtractData Notify failed.")
-					err = bs.SaveUnscanRecord(unscanRecord)
-					if err != nil {
-						bs.wm.Log.Std.Error("block height: %d, save unscan record failed. unexpected error: %v", height, err.Error())
-					}
-
+					bs.recordUnscanFailure(height, txID, fmt.Sprintf("ExtractData Notify failed: %v", err))
+					failed = append(failed, txID)
 				}
 			}
 		}
 	}
 
+	if len(failed) > 0 {
+		return &ExtractNotifyError{Height: height, TxIDs: failed}
+	}
 	return nil
 }
 
-//BatchExtractTransaction 批量提取交易单
-//bitcoin 1M的区块链可以容纳3000笔交易，批量多线程处理，速度更快
+//BatchExtractTransaction 提取单个区块的交易单并通知观测者
 func (bs *ARKBlockScanner) BatchExtractTransaction(block *client.Block) error {
+	result, err := bs.ExtractTransaction(block, bs.ScanTargetFunc)
+	return bs.notifyExtractResult(block, result, err)
+}
 
-	var (
-		quit   = make(chan struct{})
-		failed = 0
-	)
+//notifyExtractResult 通知区块的提取结果，提取失败则记录未扫区块
+func (bs *ARKBlockScanner) notifyExtractResult(block *client.Block, result ExtractResult, extractErr error) error {
 
-	//生产通道
-	producer := make(chan ExtractResult)
-	defer close(producer)
+	height := uint64(block.Height)
 
-	//消费通道
-	worker := make(chan ExtractResult)
-	defer close(worker)
+	//校验失败不记录未扫区块，直接停止扫描
+	if _, ok := extractErr.(*BlockVerifyError); ok {
+		bs.haltScanning(extractErr)
+		return extractErr
+	}
 
-	//保存工作
-	saveWork := func(height uint64, result chan ExtractResult) {
-		//回收创建的地址
-		for gets := range result {
+	if extractErr != nil || !result.Success {
+		reason := ""
+		if extractErr != nil {
+			reason = extractErr.Error()
+		}
+		//记录未扫区块
+		bs.recordUnscanFailure(height, "", reason)
+		bs.wm.Log.Std.Info("block height: %d extract failed.", height)
+		return fmt.Errorf("block scanner saveWork failed")
+	}
 
-			if gets.Success {
+	//通知失败的交易已按交易记录未扫记录，不影响区块的扫描
+	notifyErr := bs.newExtractDataNotify(height, result.extractData)
+	if notifyErr != nil {
+		bs.wm.Log.Std.Info("newExtractDataNotify unexpected error: %v", notifyErr)
+	}
 
-				notifyErr := bs.newExtractDataNotify(height, gets.extractData)
-				//saveErr := bs.SaveRechargeToWalletDB(height, gets.Recharges)
-				if notifyErr != nil {
-					failed++ //标记保存失败数
-					bs.wm.Log.Std.Info("newExtractDataNotify unexpected error: %v", notifyErr)
-				}
+	for txID, err := range result.failedTxs {
+		bs.recordUnscanFailure(height, txID, err.Error())
+	}
 
-			} else {
-				//记录未扫区块
-				unscanRecord := NewUnscanRecord(height, "", "")
-				bs.SaveUnscanRecord(unscanRecord)
-				bs.wm.Log.Std.Info("block height: %d extract failed.", height)
-				failed++ //标记保存失败数
-			}
-			//累计完成的线程数
-			close(quit) //关闭通道，等于给通道传入nil
+	//记录已通知的交易，分叉时通知观测者回滚
+	txIDs := bs.reportedTxs.add(block, result.extractData, bs.wm.Config.MaxReorgDepth)
+	if len(txIDs) > 0 {
+		err := bs.saveReportedTxs(height, block.Id, txIDs, bs.wm.Config.MaxReorgDepth)
+		if err != nil {
+			bs.wm.Log.Std.Warning("block scanner can not save reported transactions of block %d; unexpected error: %v", height, err)
 		}
 	}
 
-	//提取工作
-	extractWork := func(eBlock *client.Block, eProducer chan ExtractResult) {
-		bs.extractingCH <- struct{}{}
-		//shouldDone++
-		go func(mBlock *client.Block, end chan struct{}, mProducer chan<- ExtractResult) {
-			result, err := bs.ExtractTransaction(mBlock, bs.ScanTargetFunc)
-			if err != nil {
-				log.Error("extractWork err :", err)
-			}
-			//导出提出的交易
-			mProducer <- result
-			//释放
-			<-end
+	return nil
+}
+
+//scanBlockResult 流水线中单个高度的获取及提取结果
+type scanBlockResult struct {
+	Height     uint64
+	Block      *client.Block
+	Extract    ExtractResult
+	FetchErr   error
+	ExtractErr error
+}
 
-		}(eBlock, bs.extractingCH, eProducer)
+//scanPipeline 区块扫描流水线，按高度顺序提前获取区块并并行提取交易单，每次运行按ScanConcurrency创建工作令牌。
+//返回的通道按高度顺序输出每个区块的结果通道，消费者依次等待即可保证通知顺序，关闭quit后停止生产
+func (bs *ARKBlockScanner) scanPipeline(start, end uint64, quit <-chan struct{}) <-chan chan scanBlockResult {
+	concurrency := bs.wm.Config.ScanConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultScanConcurrency
 	}
+	return bs.blockPipeline(start, end, make(chan struct{}, concurrency), quit)
+}
 
-	/*	开启导出的线程	*/
+//blockPipeline 按tokens控制并发数的区块流水线，后台重扫使用独立的tokens
+func (bs *ARKBlockScanner) blockPipeline(start, end uint64, tokens chan struct{}, quit <-chan struct{}) <-chan chan scanBlockResult {
 
-	//独立线程运行消费
-	go saveWork(uint64(block.Height), worker)
+	ordered := make(chan chan scanBlockResult, cap(tokens))
 
-	//独立线程运行生产
-	go extractWork(block, producer)
+	go func() {
+		defer close(ordered)
+		for height := start; height <= end; height++ {
 
-	//以下使用生产消费模式
-	bs.extractRuntime(producer, worker, quit)
+			//获取工作令牌
+			select {
+			case tokens <- struct{}{}:
+			case <-quit:
+				return
+			}
 
-	if failed > 0 {
-		return fmt.Errorf("block scanner saveWork failed")
-	} else {
-		return nil
+			result := make(chan scanBlockResult, 1)
+			go func(height uint64) {
+				defer func() { <-tokens }()
+				result <- bs.fetchAndExtractBlock(height)
+			}(height)
+
+			select {
+			case ordered <- result:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	return ordered
+}
+
+//fetchAndExtractBlock 获取指定高度的区块并提取交易单
+func (bs *ARKBlockScanner) fetchAndExtractBlock(height uint64) scanBlockResult {
+	result := scanBlockResult{Height: height}
+	block, err := bs.getBlockByHeight(height)
+	if err != nil {
+		result.FetchErr = err
+		return result
 	}
+	result.Block = block
+	result.Extract, result.ExtractErr = bs.ExtractTransaction(block, bs.ScanTargetFunc)
+	return result
 }
 
-//rescanFailedRecord 重扫失败记录
+//RescanFailedRecord 重扫到期的失败记录，失败后按指数退避推迟下次重试，超过最大尝试次数的记录转入死信
 func (bs *ARKBlockScanner) RescanFailedRecord() {
 
+	defer bs.recordUnscanBacklog()
+
 	list, err := bs.GetUnscanRecords()
 	if err != nil {
 		bs.wm.Log.Std.Info("block scanner can not get rescan data; unexpected error: %v", err)
+		return
+	}
+	list = bs.migrateUnscanRecords(list)
+
+	//清理已被删除的记录，如分叉回滚删除的区块
+	exists := make(map[string]bool)
+	for _, l := range list {
+		exists[l.ID] = true
 	}
+	bs.removeUnscanRetryStates(nil, exists)
 
-	if list == nil || len(list) == 0 {
+	if len(list) == 0 {
 		bs.wm.Log.Std.Info("block scanner can not get rescan data; list is nil")
 		return
 	}
 
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].BlockHeight < list[j].BlockHeight
+	})
+
+	now := time.Now()
 	for _, l := range list {
 
 		if l.BlockHeight == 0 {
 			continue
 		}
 
-		bs.wm.Log.Std.Info("block scanner rescanning height: %d ...", l.BlockHeight)
-
-		block, err := bs.getBlockByHeight(l.BlockHeight)
-		if err != nil {
-			bs.wm.Log.Std.Info("block scanner can not get new block data; unexpected error: %v", err)
+		state := bs.unscanRetryState(l)
+		if state.DeadLetter || now.Before(state.NextRetry) {
 			continue
 		}
 
-		err = bs.BatchExtractTransaction(block)
+		bs.wm.Log.Std.Info("block scanner rescanning height: %d, txid: %s, attempts: %d ...", l.BlockHeight, l.TxID, state.Attempts)
+
+		err = bs.retryUnscanRecord(l)
 		if err != nil {
+			if _, ok := err.(*BlockVerifyError); ok {
+				return
+			}
 			bs.wm.Log.Std.Info("block scanner can not extractRechargeRecords; unexpected error: %v", err)
 			continue
 		}
 
-		//删除未扫记录
-		bs.DeleteUnscanRecord(l.BlockHeight)
+		//删除未扫记录，同高度的其他记录各自重试
+		bs.DeleteUnscanRecordByID(l.ID)
+		bs.removeUnscanRetryStates([]string{l.ID}, nil)
 	}
-
-	//bs.DeleteUnscanRecordNotFindTX()
 }
 
 //ARKBlockScanner 扫描任务
 func (bs *ARKBlockScanner) ScanBlockTask() {
 
+	bs.scanMu.Lock()
+	defer bs.scanMu.Unlock()
+
 	//获取本地区块高度
 	blockHeader, err := bs.GetScannedBlockHeader()
 	if err != nil {
@@ -598,6 +793,13 @@ func (bs *ARKBlockScanner) ScanBlockTask() {
 	currentHeight := blockHeader.Height
 	currentHash := blockHeader.Hash
 
+	//记录本次任务的扫描速度
+	start := time.Now()
+	var scanned uint64
+	defer func() {
+		bs.recordScanTask(start, scanned)
+	}()
+
 	for {
 
 		if !bs.Scanning {
@@ -613,103 +815,90 @@ func (bs *ARKBlockScanner) ScanBlockTask() {
 			break
 		}
 
+		//只扫描达到确认数的区块
+		maxHeight = bs.confirmedHeight(maxHeight)
+		bs.recordLocalHeight(currentHeight)
+
 		//是否已到最新高度
 		if currentHeight >= maxHeight {
 			bs.wm.Log.Std.Info("block scanner has scanned full chain data. Current height: %d", maxHeight)
 			break
 		}
 
-		//继续扫描下一个区块
-		currentHeight = currentHeight + 1
-
-		bs.wm.Log.Std.Info("block scanner scanning height: %d ...", currentHeight)
-
-		//获取最大高度
-		block, err := bs.getBlockByHeight(currentHeight)
-		if err != nil {
-			//记录未扫区块
-			unscanRecord := NewUnscanRecord(currentHeight, "", err.Error())
-			bs.SaveUnscanRecord(unscanRecord)
-			bs.wm.Log.Std.Info("block height: %d extract failed.", currentHeight)
-			return
-		}
-
-		hash := block.Id
+		//流水线提前获取并提取后续区块，按高度顺序通知
+		quit := make(chan struct{})
+		ordered := bs.scanPipeline(currentHeight+1, maxHeight, quit)
+		stop := false
 
-		isFork := false
+		for resultCH := range ordered {
 
-		//判断hash是否上一区块的hash
-		if currentHash != block.Previous {
-
-			bs.wm.Log.Std.Info("block has been fork on height: %d.", currentHeight)
-			bs.wm.Log.Std.Info("block height: %d local hash = %s ", currentHeight-1, currentHash)
-			bs.wm.Log.Std.Info("block height: %d mainnet hash = %s ", currentHeight-1, block.Previous)
+			if !bs.Scanning {
+				stop = true
+				break
+			}
 
-			bs.wm.Log.Std.Info("delete recharge records on block height: %d.", currentHeight-1)
+			result := <-resultCH
 
-			//查询本地分叉的区块
-			forkBlock, _ := bs.GetLocalBlock(currentHeight - 1)
+			bs.wm.Log.Std.Info("block scanner scanning height: %d ...", result.Height)
 
-			//删除上一区块链的所有充值记录
-			//bs.DeleteRechargesByHeight(currentHeight - 1)
-			//删除上一区块链的未扫记录
-			bs.DeleteUnscanRecord(currentHeight - 1)
-			currentHeight = currentHeight - 2 //倒退2个区块重新扫描
-			if currentHeight <= 0 {
-				currentHeight = 1
+			if result.FetchErr != nil {
+				//记录未扫区块
+				bs.recordUnscanFailure(result.Height, "", result.FetchErr.Error())
+				bs.wm.Log.Std.Info("block height: %d extract failed.", result.Height)
+				stop = true
+				break
 			}
 
-			localBlock, err := bs.GetLocalBlock(currentHeight)
-			if err != nil {
-				bs.wm.Log.Std.Warning("block scanner can not get local block; unexpected error: %v", err)
-
-				//查找core钱包的RPC
-				bs.wm.Log.Info("block scanner prev block height:", currentHeight)
+			block := result.Block
 
-				localBlock, err = bs.getBlockByHeight(currentHeight)
+			//判断hash是否上一区块的hash
+			if currentHash != block.Previous {
+				currentHeight, currentHash, err = bs.forkRollback(result.Height, currentHash, block)
 				if err != nil {
-					bs.wm.Log.Std.Error("block scanner can not get prev block; unexpected error: %v", err)
-					break
+					stop = true
 				}
-
-			}
-
-			//重置当前区块的hash
-			currentHash = localBlock.Id
-
-			bs.wm.Log.Std.Info("rescan block on height: %d, hash: %s .", currentHeight, currentHash)
-
-			//重新记录一个新扫描起点
-			bs.SaveLocalBlockHead(uint64(localBlock.Height), localBlock.Id)
-
-			isFork = true
-
-			if forkBlock != nil {
-
-				//通知分叉区块给观测者，异步处理
-				bs.newBlockNotify(forkBlock, isFork)
+				//丢弃流水线中分叉后的结果，从新起点重新扫描
+				break
 			}
 
-		} else {
-
-			err = bs.BatchExtractTransaction(block)
+			err = bs.notifyExtractResult(block, result.Extract, result.ExtractErr)
 			if err != nil {
+				if _, ok := err.(*BlockVerifyError); ok {
+					//已停止扫描，不推进扫描高度
+					stop = true
+					break
+				}
 				bs.wm.Log.Std.Info("block scanner can not extractRechargeRecords; unexpected error: %v", err)
 			}
 
 			//重置当前区块的hash
-			currentHash = hash
+			currentHeight = result.Height
+			currentHash = block.Id
 
 			//保存本地新高度
 			bs.SaveLocalBlockHead(currentHeight, currentHash)
 			bs.SaveLocalBlock(block)
-
-			isFork = false
+			scanned++
+			bs.recordScannedBlock(currentHeight)
+			if result.ExtractErr == nil && result.Extract.Success {
+				err = bs.saveStoredBlock(block, result.Extract.transactions)
+				if err != nil {
+					bs.wm.Log.Std.Warning("block scanner can not save block %d to local block store; unexpected error: %v", result.Height, err)
+				}
+			}
 
 			//通知新区块给观测者，异步处理
-			bs.newBlockNotify(block, isFork)
+			bs.newBlockNotify(block, false)
 		}
 
+		close(quit)
+
+		if stop {
+			if !bs.Scanning {
+				return
+			}
+			break
+		}
 	}
 
 	//重扫前N个块，为保证记录找到
@@ -739,11 +928,18 @@ func (bs *ARKBlockScanner) ScanBlock(height uint64) error {
 //ScanBlock 扫描指定高度区块
 func (bs *ARKBlockScanner) scanBlock(height uint64) (*client.Block, error) {
 
-	block, err := bs.getBlockByHeight(height)
-	if err != nil {
-		return nil, err
+	//优先使用本地区块库中已扫描的区块
+	var block *client.Block
+	if stored := bs.getStoredBlock(height); stored != nil {
+		block = &stored.Block
+	} else {
+		remoteBlock, err := bs.getBlockByHeight(height)
+		if err != nil {
+			return nil, err
+		}
+		block = remoteBlock
 	}
-	err = bs.BatchExtractTransaction(block)
+	err := bs.BatchExtractTransaction(block)
 	if err != nil {
 		bs.wm.Log.Std.Info("block scanner can not extractRechargeRecords; unexpected error: %v", err)
 		return nil, err
@@ -770,24 +966,31 @@ func (bs *ARKBlockScanner) SetRescanBlockHeight(height uint64) error {
 //ExtractTransactionData
 func (bs *ARKBlockScanner) ExtractTransactionData(txid string, scanTargetFunc openwallet.BlockScanTargetFunc) (map[string][]*openwallet.TxExtractData, error) {
 
-	query := &client.Pagination{Limit: 1}
+	//优先从本地区块库读取
+	tx, block := bs.getStoredTransaction(txid)
+	if tx == nil {
+		query := &client.Pagination{Limit: 1}
 
-	trans, _, err := bs.wm.Api.Client.Transactions.ListById(bs.wm.Context, query, txid)
+		trans, _, err := bs.wm.Api.Client.Transactions.ListById(bs.wm.Context, query, txid)
+		if err == nil && trans == nil {
+			err = errEmptyNodeResponse
+		}
 
-	if err != nil {
-		return nil, err
-	}
+		if err != nil {
+			return nil, err
+		}
 
-	if trans.Data == nil || len(trans.Data) == 0 {
+		if trans.Data == nil || len(trans.Data) == 0 {
 
-		return nil, errors.New("trans.Transactions is nil")
-	}
-	tx := trans.Data[0]
-	block, err := bs.getBlockByHeight(uint64(tx.BlockHeight))
-	if err != nil {
-		return nil, err
+			return nil, errors.New("trans.Transactions is nil")
+		}
+		tx = &trans.Data[0]
+		block, err = bs.getBlockByHeight(uint64(tx.BlockHeight))
+		if err != nil {
+			return nil, err
+		}
 	}
-	result, err := bs.ExtractTransactionSingleTx(block, &tx, scanTargetFunc)
+	result, err := bs.ExtractTransactionSingleTx(block, tx, scanTargetFunc)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,503 @@
+package arkecosystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/blocktree/arkecosystem-adapter/sdk/client"
+	"github.com/blocktree/openwallet/v2/openwallet"
+)
+
+const testWatchedAddress = "AJWRd23HNEhPLkK1ymMnwnDBX2a7QBZqff"
+
+//testChain 模拟节点的区块及交易数据
+type testChain struct {
+	mu     sync.RWMutex
+	blocks []client.Block
+	txs    map[string][]client.Transaction
+}
+
+func newTestChain(height int, txsPerBlock int) *testChain {
+	chain := &testChain{txs: make(map[string][]client.Transaction)}
+	for h := 1; h <= height; h++ {
+		chain.appendBlock("", txsPerBlock)
+	}
+	return chain
+}
+
+//appendBlock 追加区块，prefix用于区分分叉链上的区块
+func (chain *testChain) appendBlock(prefix string, txCount int) {
+	height := int64(len(chain.blocks) + 1)
+	previous := ""
+	if height > 1 {
+		previous = chain.blocks[height-2].Id
+	}
+	block := client.Block{
+		Id:           fmt.Sprintf("%sblock-%d", prefix, height),
+		Height:       height,
+		Previous:     previous,
+		Transactions: uint32(txCount),
+	}
+	chain.blocks = append(chain.blocks, block)
+	for i := 0; i < txCount; i++ {
+		chain.txs[block.Id] = append(chain.txs[block.Id], client.Transaction{
+			Id:        fmt.Sprintf("%s-tx-%d", block.Id, i),
+			BlockId:   block.Id,
+			Amount:    100000000,
+			Fee:       10000000,
+			Sender:    "AUexKjGtgsSpVzPLs6jNMM6vJ6znEVTQWK",
+			Recipient: testWatchedAddress,
+		})
+	}
+}
+
+//reorg 从指定高度开始替换为新的分叉链
+func (chain *testChain) reorg(fromHeight int, height int, txsPerBlock int) {
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+	chain.blocks = chain.blocks[:fromHeight-1]
+	for h := fromHeight; h <= height; h++ {
+		chain.appendBlock("fork-", txsPerBlock)
+	}
+}
+
+func (chain *testChain) server() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc(baseURLPath+"/blocks", func(writer http.ResponseWriter, request *http.Request) {
+		chain.mu.RLock()
+		defer chain.mu.RUnlock()
+		result := client.Blocks{Data: []client.Block{}}
+		if height, _ := strconv.Atoi(request.URL.Query().Get("height")); height > 0 {
+			if height <= len(chain.blocks) {
+				result.Data = append(result.Data, chain.blocks[height-1])
+			}
+		} else {
+			result.Data = append(result.Data, chain.blocks[len(chain.blocks)-1])
+		}
+		json.NewEncoder(writer).Encode(result)
+	})
+	mux.HandleFunc(baseURLPath+"/transactions", func(writer http.ResponseWriter, request *http.Request) {
+		chain.mu.RLock()
+		defer chain.mu.RUnlock()
+		query := request.URL.Query()
+		page, _ := strconv.Atoi(query.Get("page"))
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		txs := chain.txs[query.Get("blockId")]
+		result := client.Transactions{Data: []client.Transaction{}}
+		result.Meta.TotalCount = uint32(len(txs))
+		result.Meta.PageCount = uint32((len(txs) + limit - 1) / limit)
+		for i := (page - 1) * limit; i < page*limit && i < len(txs); i++ {
+			result.Data = append(result.Data, txs[i])
+		}
+		result.Meta.Count = uint32(len(result.Data))
+		json.NewEncoder(writer).Encode(result)
+	})
+	mux.HandleFunc(baseURLPath+"/transactions/", func(writer http.ResponseWriter, request *http.Request) {
+		chain.mu.RLock()
+		defer chain.mu.RUnlock()
+		id := strings.TrimPrefix(request.URL.Path, baseURLPath+"/transactions/")
+		for _, txs := range chain.txs {
+			for _, tx := range txs {
+				if tx.Id == id {
+					json.NewEncoder(writer).Encode(client.GetTransaction{Data: tx})
+					return
+				}
+			}
+		}
+		http.Error(writer, `{"error": "Not Found"}`, http.StatusNotFound)
+	})
+	mux.HandleFunc(baseURLPath+"/wallets/", func(writer http.ResponseWriter, request *http.Request) {
+		chain.mu.RLock()
+		defer chain.mu.RUnlock()
+		address := strings.TrimSuffix(strings.TrimPrefix(request.URL.Path, baseURLPath+"/wallets/"), "/transactions")
+		query := request.URL.Query()
+		page, _ := strconv.Atoi(query.Get("page"))
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		//地址交易按时间倒序返回
+		txs := make([]client.Transaction, 0)
+		for h := len(chain.blocks); h >= 1; h-- {
+			for _, tx := range chain.txs[chain.blocks[h-1].Id] {
+				if tx.Sender == address || tx.Recipient == address {
+					tx.Confirmations = uint32(len(chain.blocks) - h + 1)
+					txs = append(txs, tx)
+				}
+			}
+		}
+		//节点未记录的钱包
+		if len(txs) == 0 {
+			http.Error(writer, `{"statusCode": 404, "error": "Not Found", "message": "Wallet not found"}`, http.StatusNotFound)
+			return
+		}
+		result := client.Transactions{Data: []client.Transaction{}}
+		result.Meta.TotalCount = uint32(len(txs))
+		result.Meta.PageCount = uint32((len(txs) + limit - 1) / limit)
+		for i := (page - 1) * limit; i < page*limit && i < len(txs); i++ {
+			result.Data = append(result.Data, txs[i])
+		}
+		result.Meta.Count = uint32(len(result.Data))
+		json.NewEncoder(writer).Encode(result)
+	})
+	return httptest.NewServer(mux)
+}
+
+//testBlockchainDAI 内存实现的区块链数据访问接口
+type testBlockchainDAI struct {
+	mu      sync.Mutex
+	head    *openwallet.BlockHeader
+	headers map[uint64]*openwallet.BlockHeader
+	unscan  map[string]*openwallet.UnscanRecord
+}
+
+func newTestBlockchainDAI() *testBlockchainDAI {
+	return &testBlockchainDAI{
+		head:    &openwallet.BlockHeader{},
+		headers: make(map[uint64]*openwallet.BlockHeader),
+		unscan:  make(map[string]*openwallet.UnscanRecord),
+	}
+}
+
+func (dai *testBlockchainDAI) SaveCurrentBlockHead(header *openwallet.BlockHeader) error {
+	dai.mu.Lock()
+	defer dai.mu.Unlock()
+	dai.head = header
+	return nil
+}
+
+func (dai *testBlockchainDAI) GetCurrentBlockHead(symbol string) (*openwallet.BlockHeader, error) {
+	dai.mu.Lock()
+	defer dai.mu.Unlock()
+	return dai.head, nil
+}
+
+func (dai *testBlockchainDAI) SaveLocalBlockHead(header *openwallet.BlockHeader) error {
+	dai.mu.Lock()
+	defer dai.mu.Unlock()
+	dai.headers[header.Height] = header
+	return nil
+}
+
+func (dai *testBlockchainDAI) GetLocalBlockHeadByHeight(height uint64, symbol string) (*openwallet.BlockHeader, error) {
+	dai.mu.Lock()
+	defer dai.mu.Unlock()
+	header, ok := dai.headers[height]
+	if !ok {
+		return nil, fmt.Errorf("block header %d not found", height)
+	}
+	return header, nil
+}
+
+func (dai *testBlockchainDAI) SaveUnscanRecord(record *openwallet.UnscanRecord) error {
+	dai.mu.Lock()
+	defer dai.mu.Unlock()
+	dai.unscan[record.ID] = record
+	return nil
+}
+
+func (dai *testBlockchainDAI) DeleteUnscanRecordByHeight(height uint64, symbol string) error {
+	dai.mu.Lock()
+	defer dai.mu.Unlock()
+	for id, record := range dai.unscan {
+		if record.BlockHeight == height {
+			delete(dai.unscan, id)
+		}
+	}
+	return nil
+}
+
+func (dai *testBlockchainDAI) DeleteUnscanRecordByID(id string, symbol string) error {
+	dai.mu.Lock()
+	defer dai.mu.Unlock()
+	delete(dai.unscan, id)
+	return nil
+}
+
+func (dai *testBlockchainDAI) GetTransactionsByTxID(txid, symbol string) ([]*openwallet.Transaction, error) {
+	return nil, nil
+}
+
+func (dai *testBlockchainDAI) GetUnscanRecords(symbol string) ([]*openwallet.UnscanRecord, error) {
+	dai.mu.Lock()
+	defer dai.mu.Unlock()
+	list := make([]*openwallet.UnscanRecord, 0)
+	for _, record := range dai.unscan {
+		list = append(list, record)
+	}
+	return list, nil
+}
+
+func (dai *testBlockchainDAI) SetMaxBlockCache(max uint64, symbol string) error {
+	return nil
+}
+
+//testObserver 记录扫描通知
+type testObserver struct {
+	mu      sync.Mutex
+	heights []uint64
+	txIDs   []string
+	data    []*openwallet.TxExtractData
+	forks   map[uint64][]string
+}
+
+func (o *testObserver) BlockScanNotify(header *openwallet.BlockHeader) error {
+	return nil
+}
+
+func (o *testObserver) BlockExtractDataNotify(sourceKey string, data *openwallet.TxExtractData) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.heights = append(o.heights, data.Transaction.BlockHeight)
+	o.txIDs = append(o.txIDs, data.Transaction.TxID)
+	o.data = append(o.data, data)
+	return nil
+}
+
+func (o *testObserver) BlockForkNotify(header *openwallet.BlockHeader, txIDs []string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.forks == nil {
+		o.forks = make(map[uint64][]string)
+	}
+	o.forks[header.Height] = txIDs
+	return nil
+}
+
+func (o *testObserver) BlockExtractSmartContractDataNotify(sourceKey string, data *openwallet.SmartContractReceipt) error {
+	return nil
+}
+
+func newTestScanner(chain *testChain) (*ARKBlockScanner, *testBlockchainDAI, *testObserver, func()) {
+	server := chain.server()
+	wm := NewWalletManager()
+	wm.Api = NewApi(server.URL)
+
+	bs := wm.Blockscanner
+	dai := newTestBlockchainDAI()
+	observer := &testObserver{}
+	bs.SetBlockchainDAI(dai)
+	bs.AddObserver(observer)
+	bs.ScanTargetFunc = func(target openwallet.ScanTarget) (string, bool) {
+		return "account", target.Address == testWatchedAddress
+	}
+	bs.Scanning = true
+	return bs, dai, observer, server.Close
+}
+
+func TestARKBlockScanner_ScanBlockTask(t *testing.T) {
+	chain := newTestChain(40, 1)
+	bs, dai, observer, closeServer := newTestScanner(chain)
+	defer closeServer()
+
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 1, Hash: "block-1"})
+	bs.ScanBlockTask()
+
+	if dai.head.Height != 40 || dai.head.Hash != "block-40" {
+		t.Errorf("scanned head got %d %s, want 40", dai.head.Height, dai.head.Hash)
+	}
+	if len(observer.heights) != 39 {
+		t.Errorf("notified %d transactions, want 39", len(observer.heights))
+		return
+	}
+	for i, height := range observer.heights {
+		if height != uint64(i+2) {
+			t.Errorf("notify order got height %d at %d, want %d", height, i, i+2)
+			return
+		}
+	}
+}
+
+func TestARKBlockScanner_ScanBlockTaskFork(t *testing.T) {
+	chain := newTestChain(20, 1)
+	bs, dai, _, closeServer := newTestScanner(chain)
+	defer closeServer()
+
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 1, Hash: "block-1"})
+	bs.ScanBlockTask()
+
+	chain.reorg(20, 25, 1)
+	bs.ScanBlockTask()
+
+	if dai.head.Height != 25 || dai.head.Hash != "fork-block-25" {
+		t.Errorf("scanned head got %d %s, want fork-block-25", dai.head.Height, dai.head.Hash)
+	}
+}
+
+func TestARKBlockScanner_ExtractTransactionPagination(t *testing.T) {
+	chain := newTestChain(3, 250)
+	bs, dai, observer, closeServer := newTestScanner(chain)
+	defer closeServer()
+
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 2, Hash: "block-2"})
+	bs.ScanBlockTask()
+
+	if len(observer.txIDs) != 250 {
+		t.Errorf("notified %d transactions, want 250", len(observer.txIDs))
+	}
+}
+
+func TestARKBlockScanner_ExtractTransactionCountMismatch(t *testing.T) {
+	chain := newTestChain(3, 150)
+	//节点返回的交易数少于区块头记录的交易数
+	chain.blocks[2].Transactions = 151
+	bs, dai, observer, closeServer := newTestScanner(chain)
+	defer closeServer()
+
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 2, Hash: "block-2"})
+	bs.ScanBlockTask()
+
+	if len(observer.txIDs) != 0 {
+		t.Errorf("partial block should not be notified, got %d transactions", len(observer.txIDs))
+	}
+	records, _ := dai.GetUnscanRecords(bs.wm.Symbol())
+	if len(records) != 1 || records[0].BlockHeight != 3 {
+		t.Errorf("unscan records got %d, want block 3", len(records))
+	}
+}
+
+func TestARKBlockScanner_ScanBlockTaskDeepReorg(t *testing.T) {
+	chain := newTestChain(20, 1)
+	bs, dai, observer, closeServer := newTestScanner(chain)
+	defer closeServer()
+
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 1, Hash: "block-1"})
+	bs.ScanBlockTask()
+
+	chain.reorg(16, 25, 1)
+	bs.ScanBlockTask()
+
+	if dai.head.Height != 25 || dai.head.Hash != "fork-block-25" {
+		t.Errorf("scanned head got %d %s, want fork-block-25", dai.head.Height, dai.head.Hash)
+	}
+	if len(observer.forks) != 5 {
+		t.Errorf("fork notified %d blocks, want 5", len(observer.forks))
+	}
+	for height := uint64(16); height <= 20; height++ {
+		txIDs := observer.forks[height]
+		if want := fmt.Sprintf("block-%d-tx-0", height); len(txIDs) != 1 || txIDs[0] != want {
+			t.Errorf("fork block %d txIDs got %v, want %s", height, txIDs, want)
+		}
+	}
+}
+
+func TestARKBlockScanner_ScanBlockTaskReorgTooDeep(t *testing.T) {
+	chain := newTestChain(20, 1)
+	bs, dai, observer, closeServer := newTestScanner(chain)
+	defer closeServer()
+	bs.wm.Config.MaxReorgDepth = 3
+
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 1, Hash: "block-1"})
+	bs.ScanBlockTask()
+
+	chain.reorg(16, 25, 1)
+	bs.ScanBlockTask()
+
+	if dai.head.Height != 20 || dai.head.Hash != "block-20" {
+		t.Errorf("scanned head got %d %s, want block-20", dai.head.Height, dai.head.Hash)
+	}
+	if len(observer.forks) != 0 {
+		t.Errorf("fork notified %d blocks, want 0", len(observer.forks))
+	}
+}
+
+func TestARKBlockScanner_ScanBlockTaskReorgMissingHeader(t *testing.T) {
+	chain := newTestChain(20, 1)
+	bs, dai, observer, closeServer := newTestScanner(chain)
+	defer closeServer()
+
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 1, Hash: "block-1"})
+	bs.ScanBlockTask()
+
+	//本地区块头缺失时无法确认共同祖先，停止回滚
+	dai.mu.Lock()
+	delete(dai.headers, 18)
+	dai.mu.Unlock()
+	chain.reorg(16, 25, 1)
+	bs.ScanBlockTask()
+
+	if dai.head.Height != 20 || dai.head.Hash != "block-20" {
+		t.Errorf("scanned head got %d %s, want block-20", dai.head.Height, dai.head.Hash)
+	}
+	if len(observer.forks) != 0 {
+		t.Errorf("fork notified %d blocks, want 0", len(observer.forks))
+	}
+}
+
+func TestARKBlockScanner_ExtractMultiPayment(t *testing.T) {
+	wm := NewWalletManager()
+	accounts := map[string]string{
+		testWatchedAddress:                   "account1",
+		"AUexKjGtgsSpVzPLs6jNMM6vJ6znEVTQWK": "account2",
+	}
+	scanTargetFunc := func(target openwallet.ScanTarget) (string, bool) {
+		key, ok := accounts[target.Address]
+		return key, ok
+	}
+
+	tx := &client.Transaction{
+		Id:        "multipayment",
+		BlockId:   "block-1",
+		Type:      6,
+		TypeGroup: 1,
+		Fee:       10000000,
+		Sender:    "AJbmGnDAwpGAUrTrZNyqXJGw7eWVa8Xa2M",
+		Asset: &client.TransactionAsset{Payments: []*client.MultiPaymentAsset{
+			{Amount: 100000000, RecipientId: testWatchedAddress},
+			{Amount: 200000000, RecipientId: "AUexKjGtgsSpVzPLs6jNMM6vJ6znEVTQWK"},
+			{Amount: 300000000, RecipientId: testWatchedAddress},
+		}},
+	}
+
+	result, err := wm.Blockscanner.changeTrans(tx, scanTargetFunc)
+	if err != nil {
+		t.Errorf("changeTrans error: %v", err)
+		return
+	}
+
+	account1 := result.extractData["account1"]
+	if account1 == nil || len(account1.TxOutputs) != 2 {
+		t.Errorf("account1 outputs got %v, want 2", account1)
+		return
+	}
+	if account1.TxOutputs[0].Index != 0 || account1.TxOutputs[1].Index != 2 ||
+		account1.TxOutputs[0].Sid == account1.TxOutputs[1].Sid || account1.TxOutputs[1].Amount != "3" {
+		t.Errorf("account1 outputs got %+v %+v", account1.TxOutputs[0], account1.TxOutputs[1])
+	}
+	account2 := result.extractData["account2"]
+	if account2 == nil || len(account2.TxOutputs) != 1 || account2.TxOutputs[0].Amount != "2" {
+		t.Errorf("account2 outputs got %v, want 1", account2)
+		return
+	}
+
+	transaction := account1.Transaction
+	if transaction.Amount != "6" || len(transaction.To) != 3 || transaction.To[1] != "AUexKjGtgsSpVzPLs6jNMM6vJ6znEVTQWK:2" {
+		t.Errorf("transaction got amount %s, to %v", transaction.Amount, transaction.To)
+	}
+}
+
+func TestARKBlockScanner_ScanBlockTaskConfirmations(t *testing.T) {
+	chain := newTestChain(30, 1)
+	bs, dai, observer, closeServer := newTestScanner(chain)
+	defer closeServer()
+	bs.wm.Config.Confirmations = 6
+
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 1, Hash: "block-1"})
+	bs.ScanBlockTask()
+
+	if dai.head.Height != 25 {
+		t.Errorf("scanned head got %d, want 25", dai.head.Height)
+	}
+	if len(observer.data) != 24 {
+		t.Fatalf("notified %d transactions, want 24", len(observer.data))
+	}
+	for _, data := range observer.data {
+		want := int64(30 - data.Transaction.BlockHeight + 1)
+		if data.Transaction.Confirm != want {
+			t.Errorf("block %d confirm got %d, want %d", data.Transaction.BlockHeight, data.Transaction.Confirm, want)
+		}
+	}
+}
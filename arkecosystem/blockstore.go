@@ -0,0 +1,262 @@
+package arkecosystem
+
+import (
+	"fmt"
+	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
+	"github.com/blocktree/arkecosystem-adapter/sdk/client"
+	"sync"
+)
+
+const (
+	//本地区块库的文件名
+	localBlockStoreFileName = "blocks.db"
+	//本地区块库默认保留的区块数
+	defaultLocalBlockRetention = 10000
+	//每保存多少个区块清理一次超出保留数量的区块
+	localBlockPruneInterval = 100
+)
+
+//storedBlock 本地保存的完整区块及其交易
+type storedBlock struct {
+	Height       uint64 `storm:"id"`
+	BlockID      string `storm:"index"`
+	Block        client.Block
+	Transactions []client.Transaction
+}
+
+//storedTransaction 交易所在的区块高度，用于按交易ID查找本地区块
+type storedTransaction struct {
+	TxID   string `storm:"id"`
+	Height uint64 `storm:"index"`
+}
+
+//storedReportedBlock 区块已通知的交易ID，分叉回滚时连同区块通知观测者，重启后仍可读取
+type storedReportedBlock struct {
+	Height uint64 `storm:"id"`
+	Hash   string
+	TxIDs  []string
+}
+
+//localBlockStore 保存已扫描的完整区块及交易，分叉回滚、重扫及ExtractTransactionData优先从本地读取，减少对节点的请求。
+//未开启完整区块时只保存已通知的交易ID
+type localBlockStore struct {
+	mu         sync.RWMutex
+	db         *storm.DB
+	retention  uint64
+	fullBlocks bool //是否保存完整区块及交易
+}
+
+//OpenLocalBlockStore 打开本地区块库，retention为保留的区块数，0为全部保留
+func (bs *ARKBlockScanner) OpenLocalBlockStore(path string, retention uint64) error {
+	return bs.openBlockStore(path, retention, true)
+}
+
+//openBlockStore 打开本地区块库，fullBlocks为false时只保存已通知的交易ID
+func (bs *ARKBlockScanner) openBlockStore(path string, retention uint64, fullBlocks bool) error {
+	bs.CloseLocalBlockStore()
+
+	db, err := storm.Open(path)
+	if err != nil {
+		return fmt.Errorf("can not open local block store %s, unexpected error: %v", path, err)
+	}
+
+	bs.blockStore.mu.Lock()
+	defer bs.blockStore.mu.Unlock()
+	bs.blockStore.db = db
+	bs.blockStore.retention = retention
+	bs.blockStore.fullBlocks = fullBlocks
+	return nil
+}
+
+//CloseLocalBlockStore 关闭本地区块库
+func (bs *ARKBlockScanner) CloseLocalBlockStore() {
+	bs.blockStore.mu.Lock()
+	defer bs.blockStore.mu.Unlock()
+	if bs.blockStore.db != nil {
+		bs.blockStore.db.Close()
+	}
+	bs.blockStore.db = nil
+}
+
+//saveStoredBlock 保存区块及交易到本地区块库，同高度的分叉区块被覆盖
+func (bs *ARKBlockScanner) saveStoredBlock(block *client.Block, transactions []client.Transaction) error {
+	bs.blockStore.mu.RLock()
+	defer bs.blockStore.mu.RUnlock()
+	if bs.blockStore.db == nil || !bs.blockStore.fullBlocks {
+		return nil
+	}
+
+	height := uint64(block.Height)
+	tx, err := bs.blockStore.db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	//删除被覆盖区块的交易索引
+	err = tx.Select(q.Eq("Height", height)).Delete(&storedTransaction{})
+	if err != nil && err != storm.ErrNotFound {
+		return err
+	}
+
+	err = tx.Save(&storedBlock{Height: height, BlockID: block.Id, Block: *block, Transactions: transactions})
+	if err != nil {
+		return err
+	}
+	for _, trans := range transactions {
+		err = tx.Save(&storedTransaction{TxID: trans.Id, Height: height})
+		if err != nil {
+			return err
+		}
+	}
+
+	retention := bs.blockStore.retention
+	if retention > 0 && height > retention && height%localBlockPruneInterval == 0 {
+		minHeight := height - retention + 1
+		err = tx.Select(q.Lt("Height", minHeight)).Delete(&storedBlock{})
+		if err != nil && err != storm.ErrNotFound {
+			return err
+		}
+		err = tx.Select(q.Lt("Height", minHeight)).Delete(&storedTransaction{})
+		if err != nil && err != storm.ErrNotFound {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+//deleteStoredBlock 删除被回滚的区块及其交易索引，该高度已保存其他区块时不删除
+func (bs *ARKBlockScanner) deleteStoredBlock(height uint64, hash string) error {
+	bs.blockStore.mu.RLock()
+	defer bs.blockStore.mu.RUnlock()
+	if bs.blockStore.db == nil || !bs.blockStore.fullBlocks {
+		return nil
+	}
+
+	tx, err := bs.blockStore.db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var stored storedBlock
+	err = tx.One("Height", height, &stored)
+	if err == storm.ErrNotFound || (err == nil && stored.BlockID != hash) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = tx.Select(q.Eq("Height", height)).Delete(&storedTransaction{})
+	if err != nil && err != storm.ErrNotFound {
+		return err
+	}
+	err = tx.DeleteStruct(&stored)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//block 指定高度的区块，调用方需持有锁
+func (store *localBlockStore) block(height uint64) (*storedBlock, error) {
+	var stored storedBlock
+	err := store.db.One("Height", height, &stored)
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+//getStoredBlock 本地区块库中指定高度的区块，未开启或不存在时返回nil
+func (bs *ARKBlockScanner) getStoredBlock(height uint64) *storedBlock {
+	bs.blockStore.mu.RLock()
+	defer bs.blockStore.mu.RUnlock()
+	if bs.blockStore.db == nil || !bs.blockStore.fullBlocks {
+		return nil
+	}
+
+	stored, err := bs.blockStore.block(height)
+	if err != nil {
+		if err != storm.ErrNotFound {
+			bs.wm.Log.Std.Warning("block scanner can not get block %d from local block store; unexpected error: %v", height, err)
+		}
+		return nil
+	}
+	return stored
+}
+
+//getStoredTransaction 本地区块库中的交易及所在区块，未开启或不存在时返回nil
+func (bs *ARKBlockScanner) getStoredTransaction(txid string) (*client.Transaction, *client.Block) {
+	bs.blockStore.mu.RLock()
+	defer bs.blockStore.mu.RUnlock()
+	if bs.blockStore.db == nil || !bs.blockStore.fullBlocks {
+		return nil, nil
+	}
+
+	var index storedTransaction
+	if bs.blockStore.db.One("TxID", txid, &index) != nil {
+		return nil, nil
+	}
+	stored, err := bs.blockStore.block(index.Height)
+	if err != nil {
+		return nil, nil
+	}
+	for i := range stored.Transactions {
+		if stored.Transactions[i].Id == txid {
+			trans := stored.Transactions[i]
+			trans.BlockHeight = stored.Block.Height
+			return &trans, &stored.Block
+		}
+	}
+	return nil, nil
+}
+
+//saveReportedTxs 保存区块已通知的交易ID，并清理超出回滚深度的记录
+func (bs *ARKBlockScanner) saveReportedTxs(height uint64, hash string, txIDs []string, depth uint64) error {
+	bs.blockStore.mu.RLock()
+	defer bs.blockStore.mu.RUnlock()
+	if bs.blockStore.db == nil {
+		return nil
+	}
+
+	tx, err := bs.blockStore.db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.Save(&storedReportedBlock{Height: height, Hash: hash, TxIDs: txIDs})
+	if err != nil {
+		return err
+	}
+	if height > depth {
+		err = tx.Select(q.Lt("Height", height-depth)).Delete(&storedReportedBlock{})
+		if err != nil && err != storm.ErrNotFound {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+//takeReportedTxs 取出并删除区块已通知的交易ID，不存在或hash不一致时返回nil
+func (bs *ARKBlockScanner) takeReportedTxs(height uint64, hash string) []string {
+	bs.blockStore.mu.RLock()
+	defer bs.blockStore.mu.RUnlock()
+	if bs.blockStore.db == nil {
+		return nil
+	}
+
+	var reported storedReportedBlock
+	if bs.blockStore.db.One("Height", height, &reported) != nil || reported.Hash != hash {
+		return nil
+	}
+	err := bs.blockStore.db.DeleteStruct(&reported)
+	if err != nil {
+		bs.wm.Log.Std.Warning("block scanner can not delete reported transactions of block %d; unexpected error: %v", height, err)
+	}
+	return reported.TxIDs
+}
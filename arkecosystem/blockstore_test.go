@@ -0,0 +1,176 @@
+package arkecosystem
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blocktree/openwallet/v2/openwallet"
+)
+
+//newBlockStoreTestScanner 开启本地区块库的测试扫描器
+func newBlockStoreTestScanner(t *testing.T, chain *testChain, retention uint64) (*ARKBlockScanner, *testBlockchainDAI, *testObserver, func()) {
+	dataDir, err := ioutil.TempDir("", "blockstore")
+	if err != nil {
+		t.Fatalf("create data dir error: %v", err)
+	}
+	bs, dai, observer, closeServer := newTestScanner(chain)
+	err = bs.OpenLocalBlockStore(filepath.Join(dataDir, localBlockStoreFileName), retention)
+	if err != nil {
+		t.Fatalf("open local block store error: %v", err)
+	}
+	return bs, dai, observer, func() {
+		closeServer()
+		bs.CloseLocalBlockStore()
+		os.RemoveAll(dataDir)
+	}
+}
+
+func TestARKBlockScanner_LocalBlockStore(t *testing.T) {
+	bs, dai, observer, closeAll := newBlockStoreTestScanner(t, newTestChain(10, 2), 0)
+	defer closeAll()
+
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 1, Hash: "block-1"})
+	bs.ScanBlockTask()
+	if dai.head.Height != 10 {
+		t.Fatalf("scanned head got %d, want 10", dai.head.Height)
+	}
+
+	//节点不可用时从本地区块库读取
+	bs.wm.Api = NewApi("http://127.0.0.1:1")
+
+	block, err := bs.GetLocalBlock(5)
+	if err != nil || block.Id != "block-5" || block.Previous != "block-4" || block.Transactions != 2 {
+		t.Errorf("local block got %+v, %v", block, err)
+	}
+
+	extData, err := bs.ExtractTransactionData("block-5-tx-1", bs.ScanTargetFunc)
+	if err != nil || len(extData["account"]) != 1 {
+		t.Fatalf("extract transaction data got %v, %v", extData, err)
+	}
+	if tx := extData["account"][0].Transaction; tx.TxID != "block-5-tx-1" || tx.BlockHeight != 5 || tx.BlockHash != "block-5" {
+		t.Errorf("extracted transaction got %+v", tx)
+	}
+
+	observer.mu.Lock()
+	notified := len(observer.txIDs)
+	observer.mu.Unlock()
+	if _, err := bs.scanBlock(5); err != nil {
+		t.Fatalf("rescan block from local block store error: %v", err)
+	}
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	if len(observer.txIDs) != notified+2 {
+		t.Errorf("rescan notified %d transactions, want 2", len(observer.txIDs)-notified)
+	}
+}
+
+func TestARKBlockScanner_LocalBlockStoreFork(t *testing.T) {
+	chain := newTestChain(20, 1)
+	bs, dai, _, closeAll := newBlockStoreTestScanner(t, chain, 0)
+	defer closeAll()
+
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 1, Hash: "block-1"})
+	bs.ScanBlockTask()
+
+	chain.reorg(15, 22, 1)
+	//回滚后分叉区块尚未扫描，被回滚的区块及交易不再可查
+	if _, _, err := bs.forkRollback(21, "block-20", &chain.blocks[20]); err != nil {
+		t.Fatalf("fork rollback error: %v", err)
+	}
+	for height := uint64(15); height <= 20; height++ {
+		if stored := bs.getStoredBlock(height); stored != nil {
+			t.Errorf("orphaned block %d should be removed, got %s", height, stored.BlockID)
+		}
+		if tx, _ := bs.getStoredTransaction(fmt.Sprintf("block-%d-tx-0", height)); tx != nil {
+			t.Errorf("transaction of orphaned block %d should be removed", height)
+		}
+	}
+
+	bs.ScanBlockTask()
+	bs.ScanBlockTask()
+	if dai.head.Height != 22 || dai.head.Hash != "fork-block-22" {
+		t.Fatalf("scanned head got %d %s, want fork-block-22", dai.head.Height, dai.head.Hash)
+	}
+
+	//分叉区块覆盖本地区块，原区块的交易不再可查
+	if stored := bs.getStoredBlock(15); stored == nil || stored.BlockID != "fork-block-15" {
+		t.Errorf("stored block 15 got %+v, want fork-block-15", stored)
+	}
+	if tx, _ := bs.getStoredTransaction("block-15-tx-0"); tx != nil {
+		t.Errorf("transaction of orphaned block should be removed")
+	}
+	if tx, block := bs.getStoredTransaction("fork-block-15-tx-0"); tx == nil || block.Id != "fork-block-15" {
+		t.Errorf("transaction of fork block got %+v", tx)
+	}
+}
+
+func TestARKBlockScanner_LocalBlockStoreRetention(t *testing.T) {
+	bs, dai, _, closeAll := newBlockStoreTestScanner(t, newTestChain(200, 1), 50)
+	defer closeAll()
+
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 1, Hash: "block-1"})
+	bs.ScanBlockTask()
+	if dai.head.Height != 200 {
+		t.Fatalf("scanned head got %d, want 200", dai.head.Height)
+	}
+
+	if bs.getStoredBlock(150) != nil {
+		t.Errorf("block 150 should be pruned")
+	}
+	if tx, _ := bs.getStoredTransaction("block-150-tx-0"); tx != nil {
+		t.Errorf("transaction of pruned block should be removed")
+	}
+	if bs.getStoredBlock(151) == nil || bs.getStoredBlock(200) == nil {
+		t.Errorf("blocks within retention should be kept")
+	}
+}
+
+func TestARKBlockScanner_ReportedTxsAfterRestart(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "blockstore")
+	if err != nil {
+		t.Fatalf("create data dir error: %v", err)
+	}
+	defer os.RemoveAll(dataDir)
+	path := filepath.Join(dataDir, localBlockStoreFileName)
+
+	chain := newTestChain(20, 1)
+	bs, dai, _, closeServer := newTestScanner(chain)
+	defer closeServer()
+	//未开启完整区块时也保存已通知的交易ID
+	if err := bs.openBlockStore(path, 0, false); err != nil {
+		t.Fatalf("open local block store error: %v", err)
+	}
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 1, Hash: "block-1"})
+	bs.ScanBlockTask()
+	if bs.getStoredBlock(20) != nil {
+		t.Errorf("full blocks should not be stored")
+	}
+	bs.CloseLocalBlockStore()
+
+	//重启后内存缓存为空，从本地区块库读取
+	restarted, _, observer, closeRestarted := newTestScanner(chain)
+	defer closeRestarted()
+	restarted.SetBlockchainDAI(dai)
+	if err := restarted.openBlockStore(path, 0, false); err != nil {
+		t.Fatalf("reopen local block store error: %v", err)
+	}
+	defer restarted.CloseLocalBlockStore()
+
+	chain.reorg(18, 25, 1)
+	restarted.ScanBlockTask()
+	if dai.head.Height != 25 || dai.head.Hash != "fork-block-25" {
+		t.Fatalf("scanned head got %d %s, want fork-block-25", dai.head.Height, dai.head.Hash)
+	}
+	for height := uint64(18); height <= 20; height++ {
+		txIDs := observer.forks[height]
+		if want := fmt.Sprintf("block-%d-tx-0", height); len(txIDs) != 1 || txIDs[0] != want {
+			t.Errorf("fork block %d txIDs got %v, want %s", height, txIDs, want)
+		}
+	}
+	if txIDs := restarted.takeReportedTxs(18, "block-18"); txIDs != nil {
+		t.Errorf("reported transactions of orphaned block should be removed, got %v", txIDs)
+	}
+}
@@ -0,0 +1,181 @@
+package arkecosystem
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/blocktree/arkecosystem-adapter/sdk/client"
+	"github.com/blocktree/arkecosystem-adapter/sdk/crypto"
+	"reflect"
+	"strings"
+)
+
+const (
+	//AIP-11序列化交易头部最小长度
+	serializedHeaderLen = 59
+)
+
+//TransactionExcessError 节点交易池已满或发送者的交易数量超过限制，交易未被接收，可稍后重新广播
+type TransactionExcessError struct {
+	TxID    string
+	Reasons []string
+}
+
+func (e *TransactionExcessError) Error() string {
+	return fmt.Sprintf("transaction [%s] exceeds the node pool limit: %s", e.TxID, strings.Join(e.Reasons, "; "))
+}
+
+//TransactionInvalidError 交易被节点判定为无效，重新广播也不会被接收
+type TransactionInvalidError struct {
+	TxID    string
+	Reasons []string
+}
+
+func (e *TransactionInvalidError) Error() string {
+	return fmt.Sprintf("transaction [%s] is invalid: %s", e.TxID, strings.Join(e.Reasons, "; "))
+}
+
+//BroadcastTransaction 广播AIP-11序列化的已签名交易，反序列化后验证签名及交易ID再提交到节点。
+//节点接收后记录发送者的nonce，交易无效时释放该nonce
+func (wm *WalletManager) BroadcastTransaction(txHex string) (string, error) {
+
+	transaction, err := wm.DecodeSerializedTransaction(txHex)
+	if err != nil {
+		return "", err
+	}
+
+	if len(transaction.Signatures) > 0 {
+		return "", fmt.Errorf("transaction [%s] is signed by multi-signature wallet, which is not supported", transaction.Id)
+	}
+
+	verified, err := transaction.Verify()
+	if err != nil || !verified {
+		return "", fmt.Errorf("transaction [%s] signature verify failed, unexpected error: %v", transaction.Id, err)
+	}
+
+	publicKey, err := wm.Config.Crypto.PublicKeyFromHex(transaction.SenderPublicKey)
+	if err != nil {
+		return "", fmt.Errorf("transaction [%s] sender public key is invalid, unexpected error: %v", transaction.Id, err)
+	}
+	sender := publicKey.ToAddress()
+
+	txID, err := wm.submitTransaction(transaction)
+	switch err.(type) {
+	case nil:
+		wm.NonceManager.Commit(sender, transaction.Nonce, txID)
+	case *TransactionInvalidError:
+		wm.NonceManager.Release(sender, transaction.Nonce)
+	}
+	return txID, err
+}
+
+//DecodeSerializedTransaction 反序列化AIP-11交易，检查网络版本，并以重新序列化的结果校验交易ID
+func (wm *WalletManager) DecodeSerializedTransaction(txHex string) (transaction *crypto.Transaction, err error) {
+
+	serialized, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, fmt.Errorf("transaction decode failed, unexpected error: %v", err)
+	}
+
+	if len(serialized) < serializedHeaderLen || serialized[0] != 0xFF {
+		return nil, fmt.Errorf("transaction is not AIP-11 serialized")
+	}
+
+	if serialized[1] != 2 {
+		return nil, fmt.Errorf("transaction version %d is not supported", serialized[1])
+	}
+
+	//畸形的交易数据会导致反序列化越界
+	defer func() {
+		if r := recover(); r != nil {
+			transaction = nil
+			err = fmt.Errorf("transaction deserialize failed, unexpected error: %v", r)
+		}
+	}()
+
+	transaction = crypto.DeserializeTransaction(txHex)
+
+	network := wm.Config.Crypto.GetNetwork()
+	if transaction.Network != network.Version {
+		return nil, fmt.Errorf("transaction network %d mismatch the configured network %d", transaction.Network, network.Version)
+	}
+
+	txID := sha256.Sum256(serialized)
+	transaction.Id = hex.EncodeToString(txID[:])
+
+	if !bytes.Equal(wm.Config.Crypto.Serialize(transaction, true, true, true), serialized) {
+		return nil, fmt.Errorf("transaction [%s] id mismatch the deserialized transaction", transaction.Id)
+	}
+
+	return transaction, nil
+}
+
+//submitTransaction 提交已签名交易到节点，并根据节点返回的accept、excess、invalid结果返回交易ID或错误
+func (wm *WalletManager) submitTransaction(transaction *crypto.Transaction) (string, error) {
+
+	txID := wm.Config.Crypto.GetId(transaction)
+	clientTransaction := client.Transaction2{
+		Id:              txID,
+		Version:         uint16(transaction.Version),
+		Network:         transaction.Network,
+		TypeGroup:       uint16(transaction.TypeGroup),
+		Type:            transaction.Type,
+		Amount:          uint64(transaction.Amount),
+		Fee:             uint64(transaction.Fee),
+		SenderPublicKey: transaction.SenderPublicKey,
+		RecipientId:     transaction.RecipientId,
+		Signature:       transaction.Signature,
+		SecondSignature: transaction.SecondSignature,
+		Signatures:      transaction.Signatures,
+		VendorField:     transaction.VendorField,
+		Expiration:      transaction.Expiration,
+		Nonce:           transaction.Nonce,
+	}
+	if transaction.Asset != nil && !reflect.DeepEqual(*transaction.Asset, crypto.TransactionAsset{}) {
+		clientTransaction.Asset = transaction.Asset
+	}
+
+	body := &client.CreateTransactionRequest{
+		Transactions: []client.Transaction2{clientTransaction},
+	}
+
+	//只广播到配置的节点，通过peers发现的节点不可信
+	result, resp, err := wm.Api.Client.Transactions.Create(WithConfiguredNodes(wm.Context), body)
+	if err == nil && result == nil {
+		err = errEmptyNodeResponse
+	}
+	if err != nil {
+		return "", fmt.Errorf("submit transaction [%s] failed, unexpected error: %v", txID, err)
+	}
+
+	reasons := make([]string, 0)
+	for _, e := range result.Errors[txID] {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", e.Type, e.Message))
+	}
+
+	switch {
+	case containsString(result.Data.Accept, txID):
+		wm.Log.Infof("Transaction [%s] submitted to the network successfully.", txID)
+		return txID, nil
+	case containsString(result.Data.Excess, txID):
+		return "", &TransactionExcessError{TxID: txID, Reasons: reasons}
+	case containsString(result.Data.Invalid, txID):
+		return "", &TransactionInvalidError{TxID: txID, Reasons: reasons}
+	}
+
+	status := ""
+	if resp != nil {
+		status = resp.Status
+	}
+	return "", fmt.Errorf("transaction [%s] is not accepted by node, status: %s", txID, status)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
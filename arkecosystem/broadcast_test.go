@@ -0,0 +1,126 @@
+package arkecosystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blocktree/arkecosystem-adapter/sdk/client"
+	"github.com/blocktree/arkecosystem-adapter/sdk/crypto"
+)
+
+func testBroadcastServer(bucket string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc(baseURLPath+"/transactions", func(writer http.ResponseWriter, request *http.Request) {
+		var body client.CreateTransactionRequest
+		json.NewDecoder(request.Body).Decode(&body)
+		txID := body.Transactions[0].Id
+		fmt.Fprintf(writer,
+			`{
+			  "data": {"%s": ["%s"]},
+			  "errors": {"%s": [{"type": "ERR_TEST", "message": "%s"}]}
+			}`, bucket, txID, txID, bucket)
+	})
+	return httptest.NewServer(mux)
+}
+
+func testSerializedTransfer(wm *WalletManager) (string, *crypto.Transaction) {
+	recipient, _ := wm.Config.Crypto.AddressFromPassphrase("recipient")
+	transaction := wm.Config.Crypto.BuildTransfer(&crypto.Transaction{
+		Amount:      100000000,
+		Nonce:       1,
+		RecipientId: recipient,
+		VendorField: "memo",
+	}, "this is a top secret passphrase", "")
+	return crypto.HexEncode(wm.Config.Crypto.Serialize(transaction, true, true, true)), transaction
+}
+
+func TestWalletManager_BroadcastTransaction(t *testing.T) {
+	tests := []struct {
+		bucket string
+		check  func(err error) bool
+	}{
+		{"accept", func(err error) bool { return err == nil }},
+		{"excess", func(err error) bool { _, ok := err.(*TransactionExcessError); return ok }},
+		{"invalid", func(err error) bool { _, ok := err.(*TransactionInvalidError); return ok }},
+	}
+
+	for _, test := range tests {
+		server := testBroadcastServer(test.bucket)
+		wm := NewWalletManager()
+		wm.Api = NewApi(server.URL)
+
+		txHex, transaction := testSerializedTransfer(wm)
+		sender, _ := wm.Config.Crypto.AddressFromPassphrase("this is a top secret passphrase")
+		//本地已分配的nonce
+		account := wm.NonceManager.account(sender)
+		account.local[transaction.Nonce] = &localNonce{}
+
+		txID, err := wm.BroadcastTransaction(txHex)
+		if !test.check(err) {
+			t.Errorf("BroadcastTransaction [%s] unexpected error: %v", test.bucket, err)
+		}
+		if err == nil && txID != transaction.Id {
+			t.Errorf("BroadcastTransaction txID got %s, want %s", txID, transaction.Id)
+		}
+
+		//接收后记录nonce对应的交易，无效时释放，超限时保留以便重新广播
+		local, reserved := account.local[transaction.Nonce]
+		switch test.bucket {
+		case "accept":
+			if !reserved || !local.Submitted || local.TxID != transaction.Id {
+				t.Errorf("accepted nonce got %+v", local)
+			}
+		case "excess":
+			if !reserved || local.Submitted {
+				t.Errorf("excess nonce got %+v", local)
+			}
+		case "invalid":
+			if reserved {
+				t.Errorf("invalid nonce should be released")
+			}
+		}
+		t.Logf("BroadcastTransaction [%s]: %s, %v", test.bucket, txID, err)
+		server.Close()
+	}
+}
+
+func TestWalletManager_DecodeSerializedTransaction(t *testing.T) {
+	wm := NewWalletManager()
+	txHex, transaction := testSerializedTransfer(wm)
+
+	decoded, err := wm.DecodeSerializedTransaction(txHex)
+	if err != nil {
+		t.Errorf("DecodeSerializedTransaction error: %v", err)
+		return
+	}
+	if decoded.Id != transaction.Id || decoded.VendorField != "memo" {
+		t.Errorf("DecodeSerializedTransaction got %s, want %s", decoded.Id, transaction.Id)
+	}
+
+	for _, invalid := range []string{"zz", txHex[:100], txHex + "0000"} {
+		if _, err := wm.DecodeSerializedTransaction(invalid); err == nil {
+			t.Errorf("DecodeSerializedTransaction should reject %s", invalid)
+		}
+	}
+
+	devnet, _ := crypto.NetworkByName("devnet")
+	wm.Config.Crypto.SetNetwork(devnet)
+	if _, err := wm.DecodeSerializedTransaction(txHex); err == nil {
+		t.Errorf("DecodeSerializedTransaction should reject transaction of another network")
+	}
+}
+
+func TestWalletManager_BroadcastTransactionEmptyResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	defer server.Close()
+
+	wm := NewWalletManager()
+	wm.Api = NewApi(server.URL)
+	txHex, _ := testSerializedTransfer(wm)
+	if _, err := wm.BroadcastTransaction(txHex); err == nil {
+		t.Errorf("BroadcastTransaction with empty response should fail")
+	}
+}
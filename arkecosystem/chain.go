@@ -0,0 +1,51 @@
+package arkecosystem
+
+import (
+	"fmt"
+	"github.com/blocktree/arkecosystem-adapter/sdk/crypto"
+)
+
+//ChainParams ARK衍生链（bridgechain）的链参数，每条链创建独立的WalletManager
+type ChainParams struct {
+	//币种标识，注册到openw的symbol
+	Symbol string
+	//币种全名
+	FullName string
+	//小数位精度
+	Decimals int32
+	//默认的networkID，配置文件未设置networkID时使用
+	NetworkID string
+	//网络参数：地址版本、wif版本、epoch、nethash
+	Network *crypto.Network
+	//各类交易的默认手续费，key为crypto.TRANSACTION_TYPES，未设置的类型使用ARK默认值
+	Fees map[uint16]crypto.FlexToshi
+}
+
+//DefaultChainParams ARK主网的链参数
+func DefaultChainParams() ChainParams {
+	network, _ := crypto.NetworkByName("mainnet")
+	return ChainParams{
+		Symbol:    Symbol,
+		FullName:  "arkecosystem",
+		Decimals:  8,
+		NetworkID: "mainnet",
+		Network:   network,
+	}
+}
+
+//check 检查链参数是否完整
+func (params ChainParams) check() error {
+	if len(params.Symbol) == 0 {
+		return fmt.Errorf("chain symbol is empty")
+	}
+	if len(params.NetworkID) == 0 {
+		return fmt.Errorf("chain [%s] networkID is empty", params.Symbol)
+	}
+	if params.Network == nil || params.Network.Version == 0 {
+		return fmt.Errorf("chain [%s] network address version is empty", params.Symbol)
+	}
+	if params.Decimals < 0 {
+		return fmt.Errorf("chain [%s] decimals is invalid", params.Symbol)
+	}
+	return nil
+}
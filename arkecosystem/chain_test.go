@@ -0,0 +1,87 @@
+package arkecosystem
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/astaxie/beego/config"
+	"github.com/blocktree/arkecosystem-adapter/sdk/crypto"
+)
+
+func TestNewWalletManagerWithChain(t *testing.T) {
+	pub, _ := hex.DecodeString("034151a3ec46b5670a682b0a63394f863587d1bc97483b1b6c70eb58e7f0aed192")
+
+	bridgechain, err := NewWalletManagerWithChain(ChainParams{
+		Symbol:    "BIND",
+		FullName:  "compendia",
+		Decimals:  8,
+		NetworkID: "bind_mainnet",
+		Network: &crypto.Network{
+			Epoch:   time.Date(2020, 3, 28, 12, 0, 0, 0, time.UTC),
+			Version: 88,
+			Wif:     188,
+		},
+		Fees: map[uint16]crypto.FlexToshi{
+			crypto.TRANSACTION_TYPES.Transfer: 1000000,
+		},
+	})
+	if err != nil {
+		t.Errorf("NewWalletManagerWithChain error: %v", err)
+		return
+	}
+	ark := NewWalletManager()
+
+	if bridgechain.Symbol() != "BIND" || bridgechain.FullName() != "compendia" {
+		t.Errorf("chain symbol got %s (%s)", bridgechain.Symbol(), bridgechain.FullName())
+	}
+	if bridgechain.transferFees() == ark.transferFees() {
+		t.Errorf("chain fees should be independent, got %s", bridgechain.transferFees())
+	}
+
+	bindAddr, _ := bridgechain.Decoder.PublicKeyToAddress(pub, false)
+	arkAddr, _ := ark.Decoder.PublicKeyToAddress(pub, false)
+	if bindAddr == arkAddr {
+		t.Errorf("chain address should be independent, got %s", bindAddr)
+	}
+	t.Logf("BIND address: %s, ARK address: %s", bindAddr, arkAddr)
+
+	_, err = NewWalletManagerWithChain(ChainParams{Symbol: "BIND", NetworkID: "bind_mainnet"})
+	if err == nil {
+		t.Errorf("NewWalletManagerWithChain should reject chain without network")
+	}
+}
+
+func TestWalletConfig_LoadNetworkCustom(t *testing.T) {
+	custom := `
+networkID = "bridgechain"
+epoch = "2020-03-28T12:00:00.000Z"
+pubKeyHash = 88
+wif = 188
+`
+	//自定义网络缺少任一参数时不沿用基础网络的参数
+	for _, key := range []string{"epoch", "pubKeyHash", "wif"} {
+		lines := make([]string, 0)
+		for _, line := range strings.Split(custom, "\n") {
+			if !strings.HasPrefix(line, key+" ") {
+				lines = append(lines, line)
+			}
+		}
+		c, _ := config.NewConfigData("ini", []byte(strings.Join(lines, "\n")))
+		err := NewConfig(Symbol).loadNetwork(c)
+		if err == nil || !strings.Contains(err.Error(), key+" is required") {
+			t.Errorf("custom network without %s got error: %v", key, err)
+		}
+	}
+
+	c, _ := config.NewConfigData("ini", []byte(custom))
+	wc := NewConfig(Symbol)
+	if err := wc.loadNetwork(c); err != nil {
+		t.Fatalf("load custom network error: %v", err)
+	}
+	network := wc.Crypto.GetNetwork()
+	if network.Version != 88 || network.Wif != 188 || !network.Epoch.Equal(time.Date(2020, 3, 28, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("custom network got %+v", network)
+	}
+}
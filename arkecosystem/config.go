@@ -1,10 +1,14 @@
 package arkecosystem
 
 import (
+	"fmt"
+	"github.com/astaxie/beego/config"
+	"github.com/blocktree/arkecosystem-adapter/sdk/crypto"
 	"github.com/blocktree/go-owcrypt"
 	"github.com/blocktree/openwallet/v2/common/file"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 const (
@@ -15,18 +19,89 @@ const (
 	//默认配置内容
 	defaultConfig = `
 
-# RPC api url
+# RPC api url, multiple nodes are separated by comma, e.g. "http://127.0.0.1:4003,http://127.0.0.2:4003"
 serverAPI = ""
-# AE networkID, default(mainnet) networkID = "ae_mainnet",
-networkID = "ae_mainnet"
-# fix fees for transaction
-fixFees = "0.00002"
+# discover more api nodes from the peers of serverAPI, discovered nodes are not authenticated, so they are
+# only used for queries after the configured nodes, and never for broadcasts or the block quorum
+discoverPeers = false
+# seconds between health checks of api nodes, default 30
+healthCheckInterval = 30
+# ARK networkID: mainnet, devnet, testnet or a custom bridgechain name, default(mainnet) networkID = "mainnet"
+networkID = "mainnet"
+# network epoch (RFC3339), required for a custom networkID, e.g. "2017-03-21T13:00:00.000Z"
+epoch = ""
+# address version byte, required for a custom networkID, e.g. 23
+pubKeyHash = ""
+# WIF version byte, required for a custom networkID, e.g. 170
+wif = ""
+# expected nethash of the network, required when the network has no predefined nethash (testnet or a custom networkID)
+nethash = ""
+# fix fees for transaction, default(empty) is the static transfer fee of the node
+fixFees = ""
+# number of blocks fetched and extracted in parallel while scanning, default 3
+scanConcurrency = 3
+# number of confirmations a block needs before its transactions are notified, default 1 (notify at the tip)
+confirmations = 1
+# max number of blocks walked back to find the common ancestor when the chain reorganizes, default 100
+maxReorgDepth = 100
+# number of api nodes that must agree on the id and previous id of a block before it is scanned,
+# a height is rejected when different blocks reach the quorum, 0 or 1 disables the cross-check, default 0
+blockQuorum = 0
+# number of configured api nodes queried for the block quorum, nodes found by discoverPeers never vote,
+# default 0 queries all configured nodes
+blockQuorumNodes = 0
+# verify transaction ids, signatures and block payload hash/generator signature locally while scanning,
+# a mismatch halts the scanner, only version 2 (AIP-11) transactions can be verified, default false
+verifyBlocks = false
+# listen address of the embedded receiver for ARK Core webhooks (block.forged, transaction.applied),
+# a received webhook triggers a scan immediately and polling is kept as a fallback, default(empty) disabled, e.g. ":4100"
+webhookListen = ""
+# http path of the webhook receiver, default "/webhook"
+webhookPath = "/webhook"
+# token returned by ARK Core when the webhook was registered, required by the webhook receiver
+webhookToken = ""
+# max attempts of a failed block or transaction before it is moved to the dead letter list, 0 retries forever, default 10
+unscanMaxAttempts = 10
+# seconds before the first retry of a failed block or transaction, doubled after each failure, default 60
+unscanRetryInterval = 60
+# max seconds between retries of a failed block or transaction, default 3600
+unscanMaxRetryInterval = 3600
+# keep full blocks and their transactions in a local database under dataDir, so fork rollback, rescans
+# and ExtractTransactionData can work without refetching from the node, default false.
+# the transaction ids reported for recent blocks are always kept there for fork notifications
+localBlockStore = false
+# number of recent blocks kept in the local block store, 0 keeps all blocks, default 10000
+localBlockRetention = 10000
+# listen address of the embedded metrics server, serving prometheus metrics on /metrics and the scanner
+# health summary on /health, default(empty) disabled, e.g. ":9100"
+metricsListen = ""
+# seconds without scan progress while behind the node, or without a node height while scanning,
+# before the scanner is reported as stalled, default 300
+scanStallTimeout = 300
+# notify unconfirmed transactions of the node pool before they are forged, default false
+mempoolScan = false
+# seconds between pool scans, default 10
+mempoolScanInterval = 10
+# seconds to keep a locally assigned nonce which is not seen in the node pool, default 300
+nonceExpireTime = 300
 `
+
+	//默认通知交易所需的区块确认数
+	defaultConfirmations = 1
+
+	//旧版本默认的networkID
+	legacyNetworkID = "ae_mainnet"
 )
 
 type WalletConfig struct {
 	//币种
 	Symbol string
+	//币种全名
+	FullName string
+	//小数位精度
+	Decimals int32
+	//链参数
+	chain ChainParams
 	//配置文件路径
 	configFilePath string
 	//配置文件名
@@ -35,21 +110,62 @@ type WalletConfig struct {
 	//BlockchainFile string
 	//本地数据库文件路径
 	dbPath string
-	//钱包服务API
+	//钱包服务API，多个节点以逗号分隔
 	ServerAPI string
+	//是否通过peers发现更多节点
+	DiscoverPeers bool
+	//节点健康检查间隔
+	HealthCheckInterval time.Duration
 	//默认配置内容
 	DefaultConfig string
 	//曲线类型
 	CurveType uint32
 	//链ID
 	NetworkID string
+	//签名上下文，包含网络参数及手续费，每个钱包实例独立
+	Crypto *crypto.Config
 	//固定手续费
 	FixFees string
 	//数据目录
 	DataDir string
-
-	//保存nonce的map
-	NonceMap map[string]uint64
+	//并行获取及提取的区块数
+	ScanConcurrency int
+	//区块达到此确认数后才通知其中的交易
+	Confirmations uint64
+	//分叉时向前查找共同祖先区块的最大深度
+	MaxReorgDepth uint64
+	//区块需达到一致的节点数，小于2时不交叉核对
+	BlockQuorum int
+	//交叉核对区块时查询的节点数，0为全部节点
+	BlockQuorumNodes int
+	//是否在本地校验区块及交易，校验失败时停止扫描
+	VerifyBlocks bool
+	//webhook接收服务的监听地址，为空时不启动
+	WebhookListen string
+	//webhook接收路径
+	WebhookPath string
+	//注册webhook时节点返回的token
+	WebhookToken string
+	//未扫记录的最大尝试次数，超过后转入死信，0为不限制
+	UnscanMaxAttempts int
+	//未扫记录的首次重试间隔，之后每次失败翻倍
+	UnscanRetryInterval time.Duration
+	//未扫记录的最大重试间隔
+	UnscanMaxRetryInterval time.Duration
+	//是否在本地区块库保存完整的区块及交易
+	LocalBlockStore bool
+	//本地区块库保留的区块数，0为全部保留
+	LocalBlockRetention uint64
+	//指标及健康检查服务的监听地址，为空时不启动
+	MetricsListen string
+	//扫描停滞的判断时间
+	ScanStallTimeout time.Duration
+	//是否扫描交易池中的未确认交易
+	MempoolScan bool
+	//交易池扫描间隔
+	MempoolScanInterval time.Duration
+	//本地已分配nonce的保留时间
+	NonceExpireTime time.Duration
 }
 
 func NewConfig(symbol string) *WalletConfig {
@@ -71,7 +187,19 @@ func NewConfig(symbol string) *WalletConfig {
 	//钱包服务API
 	c.ServerAPI = ""
 
-	c.NonceMap = make(map[string]uint64)
+	c.setChain(DefaultChainParams())
+	c.NonceExpireTime = defaultNonceExpireTime
+	c.ScanConcurrency = defaultScanConcurrency
+	c.MaxReorgDepth = defaultMaxReorgDepth
+	c.Confirmations = defaultConfirmations
+	c.MempoolScanInterval = defaultMempoolScanInterval
+	c.WebhookPath = defaultWebhookPath
+	c.HealthCheckInterval = defaultHealthCheckInterval
+	c.UnscanMaxAttempts = defaultUnscanMaxAttempts
+	c.LocalBlockRetention = defaultLocalBlockRetention
+	c.UnscanRetryInterval = defaultUnscanRetryInterval
+	c.UnscanMaxRetryInterval = defaultUnscanMaxRetryInterval
+	c.ScanStallTimeout = defaultScanStallTimeout
 	//创建目录
 	//file.MkdirAll(c.dbPath)
 
@@ -92,3 +220,84 @@ func (wc *WalletConfig) makeDataDir() {
 	//创建目录
 	file.MkdirAll(wc.dbPath)
 }
+
+//setChain 设置链参数，重置网络参数及手续费
+func (wc *WalletConfig) setChain(params ChainParams) {
+	wc.chain = params
+	wc.FullName = params.FullName
+	wc.Decimals = params.Decimals
+	wc.NetworkID = params.NetworkID
+
+	network := *params.Network
+	wc.Crypto = crypto.NewConfig(&network)
+	for transactionType, fee := range params.Fees {
+		wc.Crypto.SetFee(transactionType, fee)
+	}
+}
+
+//loadNetwork 根据networkID及epoch、pubKeyHash、wif、nethash加载网络参数
+func (wc *WalletConfig) loadNetwork(c config.Configer) error {
+
+	networkID := c.String("networkID")
+	if len(networkID) == 0 {
+		networkID = wc.chain.NetworkID
+	} else if networkID == legacyNetworkID {
+		networkID = "mainnet"
+	}
+
+	var (
+		network      *crypto.Network
+		isPredefined bool
+	)
+	if networkID == wc.chain.NetworkID {
+		//链参数自带的网络
+		chainNetwork := *wc.chain.Network
+		network, isPredefined = &chainNetwork, true
+	} else {
+		network, isPredefined = crypto.NetworkByName(networkID)
+	}
+	if !isPredefined {
+		//自定义网络，以链参数的网络为基础，必须配置创世时间、地址版本及WIF版本，避免沿用基础网络的参数
+		chainNetwork := *wc.chain.Network
+		network = &chainNetwork
+		network.Nethash = ""
+		for _, key := range []string{"epoch", "pubKeyHash", "wif"} {
+			if len(c.String(key)) == 0 {
+				return fmt.Errorf("networkID [%s] is not predefined, %s is required", networkID, key)
+			}
+		}
+	}
+
+	if epoch := c.String("epoch"); len(epoch) > 0 {
+		t, err := time.Parse(time.RFC3339, epoch)
+		if err != nil {
+			return fmt.Errorf("invalid epoch [%s], unexpected error: %v", epoch, err)
+		}
+		network.Epoch = t.UTC()
+	}
+
+	if len(c.String("pubKeyHash")) > 0 {
+		version, err := c.Int("pubKeyHash")
+		if err != nil || version <= 0 || version > 0xFF {
+			return fmt.Errorf("invalid pubKeyHash [%s]", c.String("pubKeyHash"))
+		}
+		network.Version = byte(version)
+	}
+
+	if len(c.String("wif")) > 0 {
+		wif, err := c.Int("wif")
+		if err != nil || wif <= 0 || wif > 0xFF {
+			return fmt.Errorf("invalid wif [%s]", c.String("wif"))
+		}
+		network.Wif = byte(wif)
+	}
+
+	if nethash := c.String("nethash"); len(nethash) > 0 {
+		network.Nethash = strings.ToLower(nethash)
+	}
+
+	wc.NetworkID = networkID
+	wc.Crypto.SetNetwork(network)
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package arkecosystem
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	//保留的最近健康事件数量
+	maxHealthEvents = 100
+	//健康摘要中包含的最近健康事件数量
+	healthSummaryEvents = 10
+	//默认的扫描停滞判断时间
+	defaultScanStallTimeout = 5 * time.Minute
+)
+
+//扫描器健康事件类型
+const (
+	//节点返回的区块不一致
+	HealthEventQuorumDisagreement = "quorumDisagreement"
+	//一致的节点数未达到要求，区块未被扫描
+	HealthEventQuorumFailed = "quorumFailed"
+)
+
+//ScannerHealthEvent 扫描器健康事件
+type ScannerHealthEvent struct {
+	Type    string
+	Height  uint64
+	Message string
+	//各节点的返回结果，节点地址对应区块ID或错误信息
+	Nodes map[string]string
+	Time  time.Time
+}
+
+//ScannerHealthObserver 扫描器健康观测者，观测者实现该接口后，每个健康事件都会通知
+type ScannerHealthObserver interface {
+	//ScannerHealthNotify 扫描器健康事件
+	ScannerHealthNotify(event *ScannerHealthEvent) error
+}
+
+//healthEventLog 近期的健康事件
+type healthEventLog struct {
+	mu     sync.Mutex
+	events []*ScannerHealthEvent
+}
+
+func (l *healthEventLog) add(event *ScannerHealthEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+	if len(l.events) > maxHealthEvents {
+		l.events = l.events[len(l.events)-maxHealthEvents:]
+	}
+}
+
+func (l *healthEventLog) list() []*ScannerHealthEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	events := make([]*ScannerHealthEvent, len(l.events))
+	copy(events, l.events)
+	return events
+}
+
+//HealthEvents 近期的扫描器健康事件，按发生时间排序
+func (bs *ARKBlockScanner) HealthEvents() []*ScannerHealthEvent {
+	return bs.healthEvents.list()
+}
+
+//healthNotify 记录健康事件并通知观测者
+func (bs *ARKBlockScanner) healthNotify(event *ScannerHealthEvent) {
+	event.Time = time.Now()
+	bs.healthEvents.add(event)
+
+	bs.wm.Log.Std.Warning("block scanner health event [%s] on height %d: %s %v", event.Type, event.Height, event.Message, event.Nodes)
+
+	for o, _ := range bs.Observers {
+		healthObserver, ok := o.(ScannerHealthObserver)
+		if !ok {
+			continue
+		}
+		err := healthObserver.ScannerHealthNotify(event)
+		if err != nil {
+			bs.wm.Log.Error("ScannerHealthNotify unexpected error:", err)
+		}
+	}
+}
+
+//ScannerHealthSummary 扫描器健康摘要
+type ScannerHealthSummary struct {
+	Healthy       bool                  `json:"healthy"`
+	Scanning      bool                  `json:"scanning"`
+	Stalled       bool                  `json:"stalled"`       //落后节点但扫描高度长时间未推进，或长时间未获取到节点高度
+	LocalHeight   uint64                `json:"localHeight"`   //本地已扫描的高度
+	NodeHeight    uint64                `json:"nodeHeight"`    //最近获取的节点最新高度
+	Lag           uint64                `json:"lag"`           //达到确认数但未扫描的区块数
+	LastBlockTime time.Time             `json:"lastBlockTime"` //最近一次扫描高度推进的时间
+	LastScanTime  time.Time             `json:"lastScanTime"`  //最近一次扫描任务结束的时间
+	UnscanBacklog int                   `json:"unscanBacklog"` //待重试的未扫记录数
+	DeadLetters   int                   `json:"deadLetters"`   //转入死信的未扫记录数
+	HaltError     string                `json:"haltError,omitempty"`
+	Problems      []string              `json:"problems,omitempty"`
+	RecentEvents  []*ScannerHealthEvent `json:"recentEvents,omitempty"`
+}
+
+//scanLag 达到确认数但未扫描的区块数
+func (bs *ARKBlockScanner) scanLag(localHeight uint64) uint64 {
+	bs.chainHeightMu.RLock()
+	chainHeight := bs.chainHeight
+	bs.chainHeightMu.RUnlock()
+
+	confirmed := bs.confirmedHeight(chainHeight)
+	if confirmed <= localHeight {
+		return 0
+	}
+	return confirmed - localHeight
+}
+
+//unscanBacklog 待重试及转入死信的未扫记录数
+func (bs *ARKBlockScanner) unscanBacklog() (int, int) {
+	records, err := bs.GetUnscanRecords()
+	if err != nil {
+		return 0, 0
+	}
+	deadLetters := 0
+	for _, state := range bs.DeadLetterRecords() {
+		for _, record := range records {
+			if record.ID == state.ID {
+				deadLetters++
+				break
+			}
+		}
+	}
+	return len(records) - deadLetters, deadLetters
+}
+
+//HealthSummary 扫描器健康摘要，不访问节点。
+//落后节点且扫描高度超过ScanStallTimeout未推进，或扫描中超过ScanStallTimeout未获取到节点高度时视为停滞，
+//停滞、校验失败停止扫描或未在扫描时为不健康
+func (bs *ARKBlockScanner) HealthSummary() *ScannerHealthSummary {
+
+	summary := &ScannerHealthSummary{Scanning: bs.Scanning}
+
+	summary.LocalHeight, _, _ = bs.GetLocalBlockHead()
+	bs.chainHeightMu.RLock()
+	summary.NodeHeight = bs.chainHeight
+	bs.chainHeightMu.RUnlock()
+	summary.Lag = bs.scanLag(summary.LocalHeight)
+	summary.UnscanBacklog, summary.DeadLetters = bs.unscanBacklog()
+
+	stats := bs.scanStats.snapshot()
+	summary.LastBlockTime = stats.lastAdvance
+	summary.LastScanTime = stats.lastTaskEnd
+
+	timeout := bs.wm.Config.ScanStallTimeout
+	if timeout <= 0 {
+		timeout = defaultScanStallTimeout
+	}
+	since := func(t time.Time) time.Duration {
+		if t.Before(stats.createdAt) {
+			t = stats.createdAt
+		}
+		return time.Since(t)
+	}
+
+	if summary.Lag > 0 && since(stats.lastAdvance) > timeout {
+		summary.Stalled = true
+		summary.Problems = append(summary.Problems, fmt.Sprintf("scan height %d has not advanced for %v, %d blocks behind", summary.LocalHeight, since(stats.lastAdvance).Round(time.Second), summary.Lag))
+	}
+	if summary.Scanning && since(stats.lastNode) > timeout {
+		summary.Stalled = true
+		summary.Problems = append(summary.Problems, fmt.Sprintf("node height has not been refreshed for %v", since(stats.lastNode).Round(time.Second)))
+	}
+	if haltErr := bs.HaltError(); haltErr != nil {
+		summary.HaltError = haltErr.Error()
+		summary.Problems = append(summary.Problems, "scanner halted: "+haltErr.Error())
+	} else if !summary.Scanning {
+		summary.Problems = append(summary.Problems, "scanner is not running")
+	}
+	if summary.DeadLetters > 0 {
+		summary.Problems = append(summary.Problems, fmt.Sprintf("%d unscan records are dead lettered", summary.DeadLetters))
+	}
+
+	summary.Healthy = summary.Scanning && !summary.Stalled && summary.HaltError == ""
+
+	events := bs.HealthEvents()
+	if len(events) > healthSummaryEvents {
+		events = events[len(events)-healthSummaryEvents:]
+	}
+	summary.RecentEvents = events
+	return summary
+}
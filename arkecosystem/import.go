@@ -0,0 +1,117 @@
+package arkecosystem
+
+import (
+	"fmt"
+	"github.com/blocktree/arkecosystem-adapter/sdk/client"
+)
+
+const (
+	//地址历史交易的分页大小
+	importPageSize = 100
+	//分页查询期间节点高度变化时的重试次数
+	importPageRetries = 3
+)
+
+//ImportAddressHistory 导入新增观测地址的历史交易：分页查询地址的全部交易，经changeTrans提取后通知观测者，
+//不移动扫描高度。startHeight、endHeight限定导入的区块高度范围，为0时不限制，最高不超过达到确认数的高度。
+//返回通知的交易数量
+func (bs *ARKBlockScanner) ImportAddressHistory(addresses []string, startHeight, endHeight uint64) (int, error) {
+
+	if bs.ScanTargetFunc == nil {
+		return 0, fmt.Errorf("scan target func is not set")
+	}
+
+	tip, err := bs.getCurrentBlock()
+	if err != nil {
+		return 0, err
+	}
+	maxHeight := bs.confirmedHeight(uint64(tip.Height))
+	if endHeight == 0 || endHeight > maxHeight {
+		endHeight = maxHeight
+	}
+
+	notified := 0
+	imported := make(map[string]bool)
+	for _, address := range addresses {
+
+		bs.wm.Log.Std.Info("block scanner importing history of address: %s, height: %d - %d", address, startHeight, endHeight)
+
+		for page := 1; ; page++ {
+
+			txs, pageCount, err := bs.getAddressTransactions(address, page)
+			if err != nil {
+				return notified, fmt.Errorf("can not get transactions of address [%s], unexpected error: %v", address, err)
+			}
+
+			for i := range txs {
+				trans := &txs[i]
+				height := uint64(trans.BlockHeight)
+
+				//同一交易可能属于多个导入的地址
+				if imported[trans.Id] || height == 0 || height < startHeight || height > endHeight {
+					continue
+				}
+
+				result, err := bs.changeTrans(trans, bs.ScanTargetFunc)
+				if err != nil {
+					return notified, fmt.Errorf("can not extract transaction [%s], unexpected error: %v", trans.Id, err)
+				}
+				imported[trans.Id] = true
+
+				if len(result.extractData) == 0 {
+					continue
+				}
+				bs.newExtractDataNotify(height, []*ExtractTxResult{&result})
+				notified++
+			}
+
+			if len(txs) == 0 || uint32(page) >= pageCount {
+				break
+			}
+		}
+	}
+
+	return notified, nil
+}
+
+//getAddressTransactions 查询地址一页的交易，按查询前后一致的节点高度及确认数计算交易所在的区块高度
+func (bs *ARKBlockScanner) getAddressTransactions(address string, page int) ([]client.Transaction, uint32, error) {
+
+	for retry := 0; retry < importPageRetries; retry++ {
+
+		before, err := bs.getCurrentBlock()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		query := &client.Pagination{Page: page, Limit: importPageSize}
+		result, resp, err := bs.wm.Api.Client.Wallets.Transactions(bs.wm.Context, address, query)
+		err = checkNodeResponse(resp, err)
+		if err == nil && result == nil {
+			err = errEmptyNodeResponse
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		after, err := bs.getCurrentBlock()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		//查询期间出了新区块，确认数与高度对不上，重新查询
+		if before.Height != after.Height {
+			continue
+		}
+
+		for i := range result.Data {
+			trans := &result.Data[i]
+			if trans.Confirmations > 0 {
+				trans.BlockHeight = after.Height - int64(trans.Confirmations) + 1
+			}
+		}
+		return result.Data, result.Meta.PageCount, nil
+	}
+
+	return nil, 0, fmt.Errorf("chain height keeps changing while getting page %d", page)
+}
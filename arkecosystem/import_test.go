@@ -0,0 +1,53 @@
+package arkecosystem
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/blocktree/openwallet/v2/openwallet"
+)
+
+func TestARKBlockScanner_ImportAddressHistory(t *testing.T) {
+	chain := newTestChain(30, 5)
+	bs, dai, observer, closeServer := newTestScanner(chain)
+	defer closeServer()
+
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 30, Hash: "block-30"})
+
+	count, err := bs.ImportAddressHistory([]string{testWatchedAddress}, 5, 10)
+	if err != nil {
+		t.Fatalf("import address history error: %v", err)
+	}
+	if count != 30 || len(observer.heights) != 30 {
+		t.Errorf("imported %d transactions, notified %d, want 30", count, len(observer.heights))
+	}
+	for _, height := range observer.heights {
+		if height < 5 || height > 10 {
+			t.Errorf("imported transaction on height %d out of range", height)
+		}
+	}
+	for _, data := range observer.data {
+		if data.Transaction.BlockHash != fmt.Sprintf("block-%d", data.Transaction.BlockHeight) {
+			t.Errorf("transaction %s block got %s on height %d", data.Transaction.TxID, data.Transaction.BlockHash, data.Transaction.BlockHeight)
+		}
+	}
+
+	//不限制高度时分页导入全部交易，发送者与收款人相同的地址不重复导入
+	count, err = bs.ImportAddressHistory([]string{testWatchedAddress, testWatchedAddress}, 0, 0)
+	if err != nil {
+		t.Fatalf("import address history error: %v", err)
+	}
+	if count != 150 {
+		t.Errorf("imported %d transactions, want 150", count)
+	}
+
+	if dai.head.Height != 30 || dai.head.Hash != "block-30" {
+		t.Errorf("import should not move the scan head, got %d %s", dai.head.Height, dai.head.Hash)
+	}
+
+	//节点返回错误状态时不视为空页
+	_, err = bs.ImportAddressHistory([]string{"AJbmGnDAoMZR8G1TnsRRgDJMkAWDhmBaK2"}, 0, 0)
+	if err == nil {
+		t.Errorf("import of unknown wallet should fail")
+	}
+}
@@ -2,9 +2,14 @@ package arkecosystem
 
 import (
 	"context"
+	"fmt"
+	"github.com/blocktree/arkecosystem-adapter/sdk/crypto"
+	"github.com/blocktree/openwallet/v2/common"
 	"github.com/blocktree/openwallet/v2/log"
 	"github.com/blocktree/openwallet/v2/openwallet"
 	"math/big"
+	"strings"
+	"time"
 )
 
 type WalletManager struct {
@@ -17,52 +22,111 @@ type WalletManager struct {
 	ContractDecoder openwallet.SmartContractDecoder //智能合约解析器
 	Blockscanner    *ARKBlockScanner                //区块扫描器
 	Api             *Api                            //本地封装的http client
+	NonceManager    *NonceManager                   //nonce管理器
 	Context         context.Context
 }
 
 func NewWalletManager() *WalletManager {
+	wm, _ := NewWalletManagerWithChain(DefaultChainParams())
+	return wm
+}
+
+//NewWalletManagerWithChain 根据链参数创建ARK衍生链的钱包管理者，各链的配置、扫描器、解析器相互独立
+func NewWalletManagerWithChain(params ChainParams) (*WalletManager, error) {
+	if err := params.check(); err != nil {
+		return nil, err
+	}
 	wm := WalletManager{}
-	wm.Config = NewConfig(Symbol)
+	wm.Config = NewConfig(params.Symbol)
+	wm.Config.setChain(params)
 	wm.Blockscanner = NewARKBlockScanner(&wm)
 	wm.Decoder = NewAddressDecoder(&wm)
 	wm.TxDecoder = NewTransactionDecoder(&wm)
+	wm.NonceManager = NewNonceManager(&wm)
 	wm.Log = log.NewOWLogger(wm.Symbol())
 
 	wm.Context = context.TODO()
-	return &wm
+	return &wm, nil
 }
 
-//GetAccountPendingTxCount
-func (wm *WalletManager) GetAccountPendingTxCount(address string) (uint64, error) {
+//LoadNodeConfiguration 从节点的/node/configuration加载网络参数及各类交易手续费
+func (wm *WalletManager) LoadNodeConfiguration() error {
+
+	result, _, err := wm.Api.Client.Node.Configuration(wm.Context)
+	if err != nil {
+		return fmt.Errorf("get node configuration failed, unexpected error: %v", err)
+	}
+	if result == nil || len(result.Data.Nethash) == 0 {
+		return fmt.Errorf("node configuration is empty")
+	}
+
+	nodeConfig := result.Data
+
+	//节点的网络参数必须与配置一致，防止向错误的链签名广播交易
+	network := *wm.Config.Crypto.GetNetwork()
+	if len(network.Nethash) == 0 {
+		return fmt.Errorf("nethash of network [%s] is not configured, can not verify the node", wm.Config.NetworkID)
+	}
+	if !strings.EqualFold(network.Nethash, nodeConfig.Nethash) {
+		return fmt.Errorf("node nethash [%s] mismatch the configured nethash [%s] of network [%s]",
+			nodeConfig.Nethash, network.Nethash, wm.Config.NetworkID)
+	}
+
+	if nodeConfig.Version > 0 && network.Version != byte(nodeConfig.Version) {
+		return fmt.Errorf("node network version %d mismatch the configured pubKeyHash %d of network [%s]",
+			nodeConfig.Version, network.Version, wm.Config.NetworkID)
+	}
 
-	//if wm.client == nil {
-	//	return 0, fmt.Errorf("lisk API is not inited")
-	//}
-	//GetPendingAccountTransactionsByPubkey有bug
-	//p := external.NewGetPendingAccountTransactionsByPubkeyParams().WithPubkey(address)
-	//result, err := wm.Api.Node.External.GetPendingAccountTransactionsByPubkey(p)
-	//if err != nil {
-	//	return 0, err
-	//}
-
-	return uint64(0), nil
+	if len(nodeConfig.Constants.Epoch) > 0 {
+		epoch, err := time.Parse(time.RFC3339, nodeConfig.Constants.Epoch)
+		if err != nil {
+			return fmt.Errorf("invalid node epoch [%s], unexpected error: %v", nodeConfig.Constants.Epoch, err)
+		}
+		if !epoch.Equal(network.Epoch) {
+			return fmt.Errorf("node epoch [%s] mismatch the configured epoch [%s] of network [%s]",
+				nodeConfig.Constants.Epoch, network.Epoch.Format(time.RFC3339), wm.Config.NetworkID)
+		}
+	}
+
+	network.Nethash = strings.ToLower(nodeConfig.Nethash)
+	wm.Config.Crypto.SetNetwork(&network)
+
+	if fees, ok := nodeConfig.Constants.Fees["staticFees"]; ok {
+		wm.Config.Crypto.SetFee(crypto.TRANSACTION_TYPES.Transfer, crypto.FlexToshi(fees.Transfer))
+		wm.Config.Crypto.SetFee(crypto.TRANSACTION_TYPES.SecondSignatureRegistration, crypto.FlexToshi(fees.SecondSignature))
+		wm.Config.Crypto.SetFee(crypto.TRANSACTION_TYPES.DelegateRegistration, crypto.FlexToshi(fees.DelegateRegistration))
+		wm.Config.Crypto.SetFee(crypto.TRANSACTION_TYPES.Vote, crypto.FlexToshi(fees.Vote))
+		wm.Config.Crypto.SetFee(crypto.TRANSACTION_TYPES.MultiSignatureRegistration, crypto.FlexToshi(fees.MultiSignature))
+		wm.Config.Crypto.SetFee(crypto.TRANSACTION_TYPES.Ipfs, crypto.FlexToshi(fees.Ipfs))
+		wm.Config.Crypto.SetFee(crypto.TRANSACTION_TYPES.MultiPayment, crypto.FlexToshi(fees.MultiPayment))
+		wm.Config.Crypto.SetFee(crypto.TRANSACTION_TYPES.DelegateResignation, crypto.FlexToshi(fees.DelegateResignation))
+		wm.Config.Crypto.SetFee(crypto.TRANSACTION_TYPES.HtlcLock, crypto.FlexToshi(fees.HtlcLock))
+		wm.Config.Crypto.SetFee(crypto.TRANSACTION_TYPES.HtlcClaim, crypto.FlexToshi(fees.HtlcClaim))
+		wm.Config.Crypto.SetFee(crypto.TRANSACTION_TYPES.HtlcRefund, crypto.FlexToshi(fees.HtlcRefund))
+	}
+
+	wm.Log.Std.Info("network [%s] nethash: %s, version: %d, epoch: %s",
+		wm.Config.NetworkID, network.Nethash, network.Version, network.Epoch.Format(time.RFC3339))
+
+	return nil
 }
 
-// BroadcastTransaction recalculates the transaction hash and sends the transaction to the node.
-func (wm *WalletManager) BroadcastTransaction(txHex string) (string, error) {
-	//txBytes, err := hex.DecodeString(txHex)
-	//if err != nil {
-	//	return "", fmt.Errorf("transaction decode failed, unexpected error: %v", err)
-	//}
-	//signedEncodedTx := aeternity.Encode(aeternity.PrefixTransaction, txBytes)
-	// calculate the hash of the decoded txRLP
-	//rlpTxHashRaw := owcrypt.Hash(txBytes, 32, owcrypt.HASH_ALG_BLAKE2B)
-	//// base58/64 encode the hash with the th_ prefix
-	//signedEncodedTxHash := aeternity.Encode(aeternity.PrefixTransactionHash, rlpTxHashRaw)
-
-	// send it to the network
-	//return postTransaction(wm.Api.Node, signedEncodedTx)
-	return "", nil
+//transferFees 转账交易手续费
+func (wm *WalletManager) transferFees() string {
+	if len(wm.Config.FixFees) > 0 {
+		return wm.Config.FixFees
+	}
+	fee := wm.Config.Crypto.GetFee(crypto.TRANSACTION_TYPES.Transfer)
+	return common.IntToDecimals(int64(fee), wm.Decimal()).String()
+}
+
+//GetAccountPendingTxCount 地址在节点交易池中未确认的交易数量
+func (wm *WalletManager) GetAccountPendingTxCount(address string) (uint64, error) {
+	txs, err := wm.getPoolTransactions(address)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(len(txs)), nil
 }
 
 func IntToBalance(balance int64) *big.Int {
@@ -0,0 +1,84 @@
+package arkecosystem
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/blocktree/arkecosystem-adapter/sdk/crypto"
+)
+
+func testNodeConfigurationServer(nethash string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc(baseURLPath+"/node/configuration", func(writer http.ResponseWriter, request *http.Request) {
+		fmt.Fprintf(writer,
+			`{
+			  "data": {
+			    "nethash": "%s",
+			    "token": "DARK",
+			    "version": 30,
+			    "constants": {
+			      "epoch": "2017-03-21T13:00:00.000Z",
+			      "fees": {
+			        "staticFees": {
+			          "transfer": 20000000,
+			          "vote": 100000000
+			        }
+			      }
+			    }
+			  }
+			}`, nethash)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestWalletManager_LoadNodeConfiguration(t *testing.T) {
+	server := testNodeConfigurationServer(crypto.NETWORKS_DEVNET.Nethash)
+	defer server.Close()
+
+	wm := NewWalletManager()
+	wm.Api = NewApi(server.URL)
+
+	mainnet, _ := crypto.NetworkByName("mainnet")
+	wm.Config.Crypto.SetNetwork(mainnet)
+	err := wm.LoadNodeConfiguration()
+	if err == nil {
+		t.Errorf("LoadNodeConfiguration should refuse a node of another network")
+		return
+	}
+	t.Logf("LoadNodeConfiguration mismatch: %v", err)
+
+	//地址版本、创世时间与节点不一致或未配置nethash时不覆盖配置
+	mismatches := map[string]func(network *crypto.Network){
+		"pubKeyHash": func(network *crypto.Network) { network.Version = 23 },
+		"epoch":      func(network *crypto.Network) { network.Epoch = network.Epoch.Add(time.Hour) },
+		"nethash":    func(network *crypto.Network) { network.Nethash = "" },
+	}
+	for name, change := range mismatches {
+		devnet, _ := crypto.NetworkByName("devnet")
+		change(devnet)
+		wm.Config.Crypto.SetNetwork(devnet)
+		if err := wm.LoadNodeConfiguration(); err == nil {
+			t.Errorf("LoadNodeConfiguration should refuse a node with mismatched %s", name)
+		}
+		if network := wm.Config.Crypto.GetNetwork(); *network != *devnet {
+			t.Errorf("network with mismatched %s is overridden: %+v", name, network)
+		}
+	}
+
+	devnet, _ := crypto.NetworkByName("devnet")
+	wm.Config.Crypto.SetNetwork(devnet)
+	err = wm.LoadNodeConfiguration()
+	if err != nil {
+		t.Errorf("LoadNodeConfiguration error: %v", err)
+		return
+	}
+	if version := wm.Config.Crypto.GetNetwork().Version; version != 30 {
+		t.Errorf("network version got %d, want 30", version)
+	}
+	if wm.transferFees() != "0.2" {
+		t.Errorf("transfer fees got %s, want 0.2", wm.transferFees())
+	}
+}
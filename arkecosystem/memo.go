@@ -0,0 +1,45 @@
+package arkecosystem
+
+import (
+	"encoding/hex"
+	"github.com/blocktree/arkecosystem-adapter/sdk/client"
+	"github.com/blocktree/openwallet/v2/openwallet"
+	"unicode/utf8"
+)
+
+//MemoScanTargetFunc 按收款地址及vendorField备注查找扫描目标，用于共用充值地址以备注区分用户的场景
+type MemoScanTargetFunc func(target openwallet.ScanTarget, memo string) (string, bool)
+
+//transactionMemo 交易的vendorField备注及其hex，非UTF-8内容只返回hex
+func transactionMemo(trans *client.Transaction) (string, string) {
+	memo := trans.VendorField
+	memoHex := trans.VendorFieldHex
+	if len(memoHex) == 0 {
+		memoHex = hex.EncodeToString([]byte(memo))
+	}
+	if len(memo) == 0 && len(memoHex) > 0 {
+		raw, err := hex.DecodeString(memoHex)
+		if err == nil {
+			memo = string(raw)
+		}
+	}
+	if !utf8.ValidString(memo) {
+		memo = ""
+	}
+	return memo, memoHex
+}
+
+//scanRecipient 查找收款地址的扫描目标，设置了MemoScanTargetFunc时优先按地址及备注查找
+func (bs *ARKBlockScanner) scanRecipient(scanTargetFunc openwallet.BlockScanTargetFunc, address, memo string) (string, bool) {
+	target := openwallet.ScanTarget{
+		Address:          address,
+		BalanceModelType: openwallet.BalanceModelTypeAddress,
+		Symbol:           bs.wm.Symbol(),
+	}
+	if bs.MemoScanTargetFunc != nil && len(memo) > 0 {
+		if sourceKey, ok := bs.MemoScanTargetFunc(target, memo); ok {
+			return sourceKey, true
+		}
+	}
+	return scanTargetFunc(target)
+}
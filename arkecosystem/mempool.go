@@ -0,0 +1,181 @@
+package arkecosystem
+
+import (
+	"github.com/blocktree/openwallet/v2/openwallet"
+	"sync"
+	"time"
+)
+
+const (
+	//默认交易池扫描间隔
+	defaultMempoolScanInterval = 10 * time.Second
+	//交易被交易池丢弃时通知的原因
+	mempoolDroppedReason = "transaction dropped from pool"
+)
+
+//poolTransaction 交易池中已通知的交易
+type poolTransaction struct {
+	extractData map[string]*openwallet.TxExtractData
+}
+
+//mempoolWatcher 交易池扫描器，定时查询交易池，提前通知与扫描目标相关的未确认交易
+type mempoolWatcher struct {
+	bs      *ARKBlockScanner
+	mu      sync.Mutex
+	pending map[string]*poolTransaction //已通知的相关交易，离开交易池后核对
+	seen    map[string]bool             //与扫描目标无关的交易，离开交易池后直接移除，不再查询节点
+	stop    chan struct{}
+}
+
+func newMempoolWatcher(bs *ARKBlockScanner) *mempoolWatcher {
+	return &mempoolWatcher{
+		bs:      bs,
+		pending: make(map[string]*poolTransaction),
+		seen:    make(map[string]bool),
+	}
+}
+
+//StartMempoolWatcher 启动交易池扫描，扫描器暂停时不查询交易池
+func (bs *ARKBlockScanner) StartMempoolWatcher(interval time.Duration) {
+	bs.StopMempoolWatcher()
+
+	if interval <= 0 {
+		interval = defaultMempoolScanInterval
+	}
+	stop := make(chan struct{})
+	bs.mempool.mu.Lock()
+	bs.mempool.stop = stop
+	bs.mempool.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				bs.ScanMempool()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+//StopMempoolWatcher 停止交易池扫描
+func (bs *ARKBlockScanner) StopMempoolWatcher() {
+	bs.mempool.mu.Lock()
+	defer bs.mempool.mu.Unlock()
+	if bs.mempool.stop != nil {
+		close(bs.mempool.stop)
+		bs.mempool.stop = nil
+	}
+}
+
+//ScanMempool 扫描一次交易池：通知新进入交易池的相关交易，并核对已离开交易池的交易
+func (bs *ARKBlockScanner) ScanMempool() {
+
+	if !bs.Scanning || bs.ScanTargetFunc == nil {
+		return
+	}
+
+	txs, err := bs.wm.listPoolTransactions()
+	if err != nil {
+		bs.wm.Log.Std.Warning("mempool watcher can not get pool transactions; unexpected error: %v", err)
+		return
+	}
+
+	watcher := bs.mempool
+	inPool := make(map[string]bool)
+	for i := range txs {
+		tx := &txs[i]
+		inPool[tx.Id] = true
+
+		watcher.mu.Lock()
+		_, exist := watcher.pending[tx.Id]
+		exist = exist || watcher.seen[tx.Id]
+		watcher.mu.Unlock()
+		if exist {
+			continue
+		}
+
+		result, err := bs.changeTrans(tx, bs.ScanTargetFunc)
+		if err != nil {
+			//下次扫描交易池时重试
+			bs.wm.Log.Std.Warning("mempool watcher can not extract transaction [%s]; unexpected error: %v", tx.Id, err)
+			continue
+		}
+
+		watcher.mu.Lock()
+		if len(result.extractData) == 0 {
+			watcher.seen[tx.Id] = true
+			watcher.mu.Unlock()
+			continue
+		}
+		watcher.pending[tx.Id] = &poolTransaction{extractData: result.extractData}
+		watcher.mu.Unlock()
+
+		bs.poolExtractDataNotify(result.extractData)
+	}
+
+	watcher.mu.Lock()
+	for txID := range watcher.seen {
+		if !inPool[txID] {
+			delete(watcher.seen, txID)
+		}
+	}
+	left := make(map[string]*poolTransaction)
+	for txID, poolTx := range watcher.pending {
+		if !inPool[txID] {
+			left[txID] = poolTx
+		}
+	}
+	watcher.mu.Unlock()
+
+	for txID, poolTx := range left {
+		bs.reconcilePoolTransaction(txID, poolTx)
+	}
+}
+
+//reconcilePoolTransaction 核对已离开交易池的交易，已打包的交易由区块扫描通知，被丢弃的交易通知失败
+func (bs *ARKBlockScanner) reconcilePoolTransaction(txID string, poolTx *poolTransaction) {
+
+	result, _, err := bs.wm.Api.Client.Transactions.Get(bs.wm.Context, txID)
+	if err == nil && result == nil {
+		err = errEmptyNodeResponse
+	}
+	if err != nil {
+		//节点异常，下次扫描时再核对
+		bs.wm.Log.Std.Warning("mempool watcher can not get transaction [%s]; unexpected error: %v", txID, err)
+		return
+	}
+
+	bs.mempool.mu.Lock()
+	delete(bs.mempool.pending, txID)
+	bs.mempool.mu.Unlock()
+
+	if result.Data.Id == txID && len(result.Data.BlockId) > 0 {
+		return
+	}
+
+	bs.wm.Log.Std.Info("transaction [%s] has been dropped from pool.", txID)
+	for _, data := range poolTx.extractData {
+		if data.Transaction == nil {
+			continue
+		}
+		data.Transaction.Status = "0"
+		data.Transaction.Reason = mempoolDroppedReason
+	}
+	bs.poolExtractDataNotify(poolTx.extractData)
+}
+
+//poolExtractDataNotify 通知交易池中的交易，确认数为0
+func (bs *ARKBlockScanner) poolExtractDataNotify(extractData map[string]*openwallet.TxExtractData) {
+	for o, _ := range bs.Observers {
+		for key, data := range extractData {
+			err := o.BlockExtractDataNotify(key, data)
+			if err != nil {
+				bs.wm.Log.Error("BlockExtractDataNotify unexpected error:", err)
+			}
+		}
+	}
+}
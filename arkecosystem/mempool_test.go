@@ -0,0 +1,129 @@
+package arkecosystem
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/blocktree/openwallet/v2/openwallet"
+)
+
+//testPoolServer 模拟交易池，forged记录已打包的交易
+type testPoolServer struct {
+	mu      sync.Mutex
+	pool    []string
+	forged  map[string]bool
+	lookups map[string]int //按交易ID查询的次数
+}
+
+func (p *testPoolServer) server() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc(baseURLPath+"/transactions/unconfirmed", func(writer http.ResponseWriter, request *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		txs := make([]string, 0)
+		for _, txID := range p.pool {
+			recipient := testWatchedAddress
+			if strings.HasPrefix(txID, "other") {
+				recipient = "AJbmGnDAoMZR8G1TnsRRgDJMkAWDhmBaK2"
+			}
+			txs = append(txs, fmt.Sprintf(`{"id": "%s", "type": 0, "typeGroup": 1, "amount": "100000000", "fee": "10000000",
+				"sender": "AUexKjGtgsSpVzPLs6jNMM6vJ6znEVTQWK", "recipient": "%s"}`, txID, recipient))
+		}
+		fmt.Fprintf(writer, `{"meta": {"count": %d, "pageCount": 1}, "data": [%s]}`, len(txs), strings.Join(txs, ","))
+	})
+	mux.HandleFunc(baseURLPath+"/transactions/", func(writer http.ResponseWriter, request *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		txID := strings.TrimPrefix(request.URL.Path, baseURLPath+"/transactions/")
+		p.lookups[txID]++
+		if !p.forged[txID] {
+			writer.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(writer, `{"statusCode": 404, "error": "Not Found", "message": "Transaction not found"}`)
+			return
+		}
+		fmt.Fprintf(writer, `{"data": {"id": "%s", "blockId": "block-10"}}`, txID)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestARKBlockScanner_ScanMempool(t *testing.T) {
+	pool := &testPoolServer{pool: []string{"a", "b", "other-1"}, forged: make(map[string]bool), lookups: make(map[string]int)}
+	server := pool.server()
+	defer server.Close()
+
+	wm := NewWalletManager()
+	wm.Api = NewApi(server.URL)
+	bs := wm.Blockscanner
+	observer := &testObserver{}
+	bs.AddObserver(observer)
+	bs.ScanTargetFunc = func(target openwallet.ScanTarget) (string, bool) {
+		return "account", target.Address == testWatchedAddress
+	}
+
+	//扫描器暂停时不查询交易池
+	bs.ScanMempool()
+	if len(observer.data) != 0 {
+		t.Errorf("paused scanner notified %d transactions", len(observer.data))
+	}
+
+	bs.Scanning = true
+	bs.ScanMempool()
+	bs.ScanMempool()
+	if len(observer.data) != 2 {
+		t.Fatalf("pool notified %d transactions, want 2", len(observer.data))
+	}
+	if len(bs.mempool.pending) != 2 || !bs.mempool.seen["other-1"] {
+		t.Errorf("only related transactions should be pending, got %d", len(bs.mempool.pending))
+	}
+	for _, data := range observer.data {
+		if data.Transaction.Confirm != 0 || data.Transaction.Status != "1" || len(data.TxOutputs) != 1 {
+			t.Errorf("pool transaction %s got confirm %d, status %s", data.Transaction.TxID, data.Transaction.Confirm, data.Transaction.Status)
+		}
+	}
+
+	//a已打包，b被交易池丢弃
+	pool.mu.Lock()
+	pool.pool = []string{}
+	pool.forged["a"] = true
+	pool.mu.Unlock()
+	bs.ScanMempool()
+
+	if len(observer.data) != 3 {
+		t.Fatalf("reconcile notified %d transactions, want 1", len(observer.data)-2)
+	}
+	dropped := observer.data[2].Transaction
+	if dropped.TxID != "b" || dropped.Status != "0" || dropped.Reason != mempoolDroppedReason {
+		t.Errorf("dropped transaction got %s, status %s", dropped.TxID, dropped.Status)
+	}
+	if len(bs.mempool.pending) != 0 {
+		t.Errorf("pending transactions should be reconciled, got %d", len(bs.mempool.pending))
+	}
+
+	//无关交易离开交易池时不查询节点
+	if pool.lookups["other-1"] != 0 || len(bs.mempool.seen) != 0 {
+		t.Errorf("unrelated transaction got %d lookups, seen %d", pool.lookups["other-1"], len(bs.mempool.seen))
+	}
+}
+
+func TestARKBlockScanner_ReconcilePoolTransactionEmptyResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	defer server.Close()
+
+	wm := NewWalletManager()
+	wm.Api = NewApi(server.URL)
+	bs := wm.Blockscanner
+	observer := &testObserver{}
+	bs.AddObserver(observer)
+
+	//节点返回空响应时保留交易，下次扫描再核对
+	poolTx := &poolTransaction{extractData: map[string]*openwallet.TxExtractData{}}
+	bs.mempool.pending["a"] = poolTx
+	bs.reconcilePoolTransaction("a", poolTx)
+	if bs.mempool.pending["a"] == nil || len(observer.data) != 0 {
+		t.Errorf("transaction should stay pending on empty response")
+	}
+}
@@ -0,0 +1,356 @@
+package arkecosystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//扫描器及节点API的指标名称
+const (
+	//本地已扫描的高度
+	MetricLocalHeight = "ark_scanner_local_height"
+	//节点最新高度
+	MetricNodeHeight = "ark_scanner_node_height"
+	//达到确认数但未扫描的区块数
+	MetricLag = "ark_scanner_lag_blocks"
+	//已扫描的区块数
+	MetricBlocksScanned = "ark_scanner_blocks_scanned_total"
+	//最近一次扫描任务的扫描速度
+	MetricBlocksPerSecond = "ark_scanner_blocks_per_second"
+	//分叉回滚次数
+	MetricForks = "ark_scanner_forks_total"
+	//待重试的未扫记录数
+	MetricUnscanBacklog = "ark_scanner_unscan_backlog"
+	//转入死信的未扫记录数
+	MetricUnscanDeadLetter = "ark_scanner_unscan_dead_letter"
+	//节点请求次数，按节点及结果(ok、error)区分
+	MetricApiRequests = "ark_api_requests_total"
+	//节点请求耗时（秒）
+	MetricApiLatency = "ark_api_request_duration_seconds"
+)
+
+//指标类型
+const (
+	metricKindCounter = "counter"
+	metricKindGauge   = "gauge"
+	metricKindSummary = "summary"
+)
+
+//Metrics 扫描器及节点API的指标接口，可替换为外部监控系统的实现
+type Metrics interface {
+	//AddCounter 累加计数器
+	AddCounter(name string, labels map[string]string, delta float64)
+	//SetGauge 设置当前值
+	SetGauge(name string, labels map[string]string, value float64)
+	//Observe 记录一次观测值，如请求耗时
+	Observe(name string, labels map[string]string, value float64)
+}
+
+//PrometheusWriter 可输出Prometheus文本格式的指标
+type PrometheusWriter interface {
+	WritePrometheus(w io.Writer) error
+}
+
+//metricSeries 单个指标序列
+type metricSeries struct {
+	name   string
+	kind   string
+	labels map[string]string
+	value  float64
+	sum    float64
+	count  uint64
+}
+
+//MemoryMetrics 内存中的指标，默认使用，实现了PrometheusWriter
+type MemoryMetrics struct {
+	mu     sync.Mutex
+	series map[string]*metricSeries
+}
+
+//NewMemoryMetrics 创建内存指标
+func NewMemoryMetrics() *MemoryMetrics {
+	return &MemoryMetrics{series: make(map[string]*metricSeries)}
+}
+
+//metricKey 指标名称及按名称排序的标签
+func metricKey(name string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", key, labels[key]))
+	}
+	if len(pairs) == 0 {
+		return name
+	}
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+func (m *MemoryMetrics) get(name, kind string, labels map[string]string) *metricSeries {
+	key := metricKey(name, labels)
+	series := m.series[key]
+	if series == nil {
+		copied := make(map[string]string, len(labels))
+		for k, v := range labels {
+			copied[k] = v
+		}
+		series = &metricSeries{name: name, kind: kind, labels: copied}
+		m.series[key] = series
+	}
+	return series
+}
+
+//AddCounter 累加计数器
+func (m *MemoryMetrics) AddCounter(name string, labels map[string]string, delta float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.get(name, metricKindCounter, labels).value += delta
+}
+
+//SetGauge 设置当前值
+func (m *MemoryMetrics) SetGauge(name string, labels map[string]string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.get(name, metricKindGauge, labels).value = value
+}
+
+//Observe 记录一次观测值，输出为summary的_sum及_count
+func (m *MemoryMetrics) Observe(name string, labels map[string]string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	series := m.get(name, metricKindSummary, labels)
+	series.sum += value
+	series.count++
+}
+
+//Value 计数器或当前值，summary返回观测次数
+func (m *MemoryMetrics) Value(name string, labels map[string]string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	series := m.series[metricKey(name, labels)]
+	if series == nil {
+		return 0
+	}
+	if series.kind == metricKindSummary {
+		return float64(series.count)
+	}
+	return series.value
+}
+
+//WritePrometheus 按Prometheus文本格式输出全部指标
+func (m *MemoryMetrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	keys := make([]string, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys)+1)
+	lastName := ""
+	for _, key := range keys {
+		series := m.series[key]
+		if series.name != lastName {
+			lines = append(lines, fmt.Sprintf("# TYPE %s %s", series.name, series.kind))
+			lastName = series.name
+		}
+		labels := strings.TrimPrefix(key, series.name)
+		if series.kind == metricKindSummary {
+			lines = append(lines, fmt.Sprintf("%s_sum%s %s", series.name, labels, formatMetricValue(series.sum)))
+			lines = append(lines, fmt.Sprintf("%s_count%s %d", series.name, labels, series.count))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s%s %s", series.name, labels, formatMetricValue(series.value)))
+	}
+	m.mu.Unlock()
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+func formatMetricValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+//SetMetrics 替换扫描器及节点API使用的指标实现
+func (bs *ARKBlockScanner) SetMetrics(metrics Metrics) {
+	bs.metricsMu.Lock()
+	bs.metrics = metrics
+	bs.metricsMu.Unlock()
+	if bs.wm.Api != nil {
+		bs.wm.Api.SetMetrics(metrics)
+	}
+}
+
+//Metrics 扫描器使用的指标实现
+func (bs *ARKBlockScanner) Metrics() Metrics {
+	bs.metricsMu.RLock()
+	defer bs.metricsMu.RUnlock()
+	return bs.metrics
+}
+
+//metricsServer 内嵌的指标及健康检查服务
+type metricsServer struct {
+	mu       sync.Mutex
+	server   *http.Server
+	listener net.Listener
+}
+
+//MetricsHandler 指标及健康检查的http处理器：/metrics输出Prometheus文本格式，/health输出健康摘要，不健康时返回503
+func (bs *ARKBlockScanner) MetricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(writer http.ResponseWriter, request *http.Request) {
+		prometheus, ok := bs.Metrics().(PrometheusWriter)
+		if !ok {
+			http.Error(writer, "metrics are not exported in prometheus format", http.StatusNotFound)
+			return
+		}
+		writer.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		prometheus.WritePrometheus(writer)
+	})
+	mux.HandleFunc("/health", func(writer http.ResponseWriter, request *http.Request) {
+		summary := bs.HealthSummary()
+		writer.Header().Set("Content-Type", "application/json")
+		if !summary.Healthy {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(writer).Encode(summary)
+	})
+	return mux
+}
+
+//StartMetricsServer 启动指标及健康检查服务
+func (bs *ARKBlockScanner) StartMetricsServer(addr string) error {
+	bs.StopMetricsServer()
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("metrics server can not listen on %s, unexpected error: %v", addr, err)
+	}
+	server := &http.Server{Handler: bs.MetricsHandler(), ReadTimeout: 30 * time.Second, WriteTimeout: 30 * time.Second}
+
+	bs.metricsServer.mu.Lock()
+	bs.metricsServer.server = server
+	bs.metricsServer.listener = listener
+	bs.metricsServer.mu.Unlock()
+
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			bs.wm.Log.Std.Error("metrics server stopped; unexpected error: %v", err)
+		}
+	}()
+
+	bs.wm.Log.Std.Info("metrics server is listening on %s", listener.Addr())
+	return nil
+}
+
+//MetricsAddr 指标服务实际监听的地址，未启动时返回空
+func (bs *ARKBlockScanner) MetricsAddr() string {
+	bs.metricsServer.mu.Lock()
+	defer bs.metricsServer.mu.Unlock()
+	if bs.metricsServer.listener == nil {
+		return ""
+	}
+	return bs.metricsServer.listener.Addr().String()
+}
+
+//StopMetricsServer 停止指标及健康检查服务
+func (bs *ARKBlockScanner) StopMetricsServer() {
+	bs.metricsServer.mu.Lock()
+	defer bs.metricsServer.mu.Unlock()
+	if bs.metricsServer.server != nil {
+		bs.metricsServer.server.Close()
+	}
+	bs.metricsServer.server = nil
+	bs.metricsServer.listener = nil
+}
+
+//scanStats 扫描进度的时间记录，用于计算扫描速度及判断扫描是否停滞
+type scanStats struct {
+	mu          sync.Mutex
+	createdAt   time.Time //扫描器创建时间
+	lastAdvance time.Time //最近一次扫描高度推进的时间
+	lastTaskEnd time.Time //最近一次扫描任务结束的时间
+	lastNode    time.Time //最近一次获取节点高度的时间
+}
+
+func newScanStats() *scanStats {
+	return &scanStats{createdAt: time.Now()}
+}
+
+func (s *scanStats) snapshot() scanStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return scanStats{createdAt: s.createdAt, lastAdvance: s.lastAdvance, lastTaskEnd: s.lastTaskEnd, lastNode: s.lastNode}
+}
+
+//recordNodeHeight 记录节点最新高度
+func (bs *ARKBlockScanner) recordNodeHeight(height uint64) {
+	bs.scanStats.mu.Lock()
+	bs.scanStats.lastNode = time.Now()
+	bs.scanStats.mu.Unlock()
+
+	if m := bs.Metrics(); m != nil {
+		m.SetGauge(MetricNodeHeight, nil, float64(height))
+	}
+}
+
+//recordLocalHeight 更新本地高度及落后的区块数
+func (bs *ARKBlockScanner) recordLocalHeight(height uint64) {
+	m := bs.Metrics()
+	if m == nil {
+		return
+	}
+	m.SetGauge(MetricLocalHeight, nil, float64(height))
+	m.SetGauge(MetricLag, nil, float64(bs.scanLag(height)))
+}
+
+//recordScannedBlock 扫描高度推进一个区块
+func (bs *ARKBlockScanner) recordScannedBlock(height uint64) {
+	bs.scanStats.mu.Lock()
+	bs.scanStats.lastAdvance = time.Now()
+	bs.scanStats.mu.Unlock()
+
+	bs.recordLocalHeight(height)
+	if m := bs.Metrics(); m != nil {
+		m.AddCounter(MetricBlocksScanned, nil, 1)
+	}
+}
+
+//recordScanTask 扫描任务结束，记录本次任务的扫描速度
+func (bs *ARKBlockScanner) recordScanTask(start time.Time, scanned uint64) {
+	now := time.Now()
+	bs.scanStats.mu.Lock()
+	bs.scanStats.lastTaskEnd = now
+	bs.scanStats.mu.Unlock()
+
+	if m := bs.Metrics(); m != nil {
+		var rate float64
+		if elapsed := now.Sub(start).Seconds(); elapsed > 0 {
+			rate = float64(scanned) / elapsed
+		}
+		m.SetGauge(MetricBlocksPerSecond, nil, rate)
+	}
+}
+
+//recordUnscanBacklog 更新待重试及死信的未扫记录数
+func (bs *ARKBlockScanner) recordUnscanBacklog() {
+	m := bs.Metrics()
+	if m == nil {
+		return
+	}
+	backlog, deadLetters := bs.unscanBacklog()
+	m.SetGauge(MetricUnscanBacklog, nil, float64(backlog))
+	m.SetGauge(MetricUnscanDeadLetter, nil, float64(deadLetters))
+}
@@ -0,0 +1,194 @@
+package arkecosystem
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blocktree/arkecosystem-adapter/sdk/client"
+	"github.com/blocktree/openwallet/v2/openwallet"
+)
+
+func TestMemoryMetrics_WritePrometheus(t *testing.T) {
+	m := NewMemoryMetrics()
+	m.SetGauge(MetricLocalHeight, nil, 12)
+	m.AddCounter(MetricApiRequests, map[string]string{"result": "ok", "node": "a:4003"}, 1)
+	m.AddCounter(MetricApiRequests, map[string]string{"node": "a:4003", "result": "ok"}, 2)
+	m.AddCounter(MetricApiRequests, map[string]string{"node": "b\"4003", "result": "error"}, 1)
+	m.Observe(MetricApiLatency, map[string]string{"node": "a:4003"}, 0.25)
+	m.Observe(MetricApiLatency, map[string]string{"node": "a:4003"}, 0.5)
+
+	if v := m.Value(MetricApiRequests, map[string]string{"node": "a:4003", "result": "ok"}); v != 3 {
+		t.Errorf("counter got %v, want 3", v)
+	}
+
+	var buf bytes.Buffer
+	if err := m.WritePrometheus(&buf); err != nil {
+		t.Fatalf("write prometheus error: %v", err)
+	}
+	want := strings.Join([]string{
+		`# TYPE ark_api_request_duration_seconds summary`,
+		`ark_api_request_duration_seconds_sum{node="a:4003"} 0.75`,
+		`ark_api_request_duration_seconds_count{node="a:4003"} 2`,
+		`# TYPE ark_api_requests_total counter`,
+		`ark_api_requests_total{node="a:4003",result="ok"} 3`,
+		`ark_api_requests_total{node="b\"4003",result="error"} 1`,
+		`# TYPE ark_scanner_local_height gauge`,
+		`ark_scanner_local_height 12`,
+	}, "\n") + "\n"
+	if buf.String() != want {
+		t.Errorf("prometheus output got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestApi_Metrics(t *testing.T) {
+	server := newTestChain(5, 1).server()
+	defer server.Close()
+
+	//第一个节点不可用，请求切换到第二个节点
+	api := NewApi("http://127.0.0.1:1," + server.URL)
+	m := NewMemoryMetrics()
+	api.SetMetrics(m)
+
+	if _, _, err := api.Client.Blocks.List(context.Background(), &client.Pagination{Limit: 1}); err != nil {
+		t.Fatalf("list blocks error: %v", err)
+	}
+
+	serverURL, _ := url.Parse(server.URL)
+	if v := m.Value(MetricApiRequests, map[string]string{"node": "127.0.0.1:1", "result": "error"}); v != 1 {
+		t.Errorf("failed node error count got %v, want 1", v)
+	}
+	if v := m.Value(MetricApiRequests, map[string]string{"node": serverURL.Host, "result": "ok"}); v != 1 {
+		t.Errorf("node ok count got %v, want 1", v)
+	}
+	if v := m.Value(MetricApiLatency, map[string]string{"node": serverURL.Host}); v != 1 {
+		t.Errorf("node latency observations got %v, want 1", v)
+	}
+}
+
+func TestARKBlockScanner_ScanMetrics(t *testing.T) {
+	chain := newTestChain(20, 1)
+	bs, dai, _, closeServer := newTestScanner(chain)
+	defer closeServer()
+	m := NewMemoryMetrics()
+	bs.SetMetrics(m)
+
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 1, Hash: "block-1"})
+	bs.ScanBlockTask()
+
+	if v := m.Value(MetricLocalHeight, nil); v != 20 {
+		t.Errorf("local height got %v, want 20", v)
+	}
+	if v := m.Value(MetricNodeHeight, nil); v != 20 {
+		t.Errorf("node height got %v, want 20", v)
+	}
+	if v := m.Value(MetricLag, nil); v != 0 {
+		t.Errorf("lag got %v, want 0", v)
+	}
+	if v := m.Value(MetricBlocksScanned, nil); v != 19 {
+		t.Errorf("blocks scanned got %v, want 19", v)
+	}
+	if v := m.Value(MetricBlocksPerSecond, nil); v <= 0 {
+		t.Errorf("blocks per second got %v", v)
+	}
+	if v := m.Value(MetricApiRequests, map[string]string{"node": bs.wm.Api.Nodes()[0].URL.Host, "result": "ok"}); v == 0 {
+		t.Errorf("api requests of the scanner are not recorded")
+	}
+
+	chain.reorg(15, 22, 1)
+	bs.ScanBlockTask()
+	bs.ScanBlockTask()
+	if v := m.Value(MetricForks, nil); v != 1 {
+		t.Errorf("forks got %v, want 1", v)
+	}
+	if v := m.Value(MetricLocalHeight, nil); v != 22 {
+		t.Errorf("local height after fork got %v, want 22", v)
+	}
+}
+
+func TestARKBlockScanner_HealthSummary(t *testing.T) {
+	bs, dai, _, closeServer := newTestScanner(newTestChain(20, 1))
+	defer closeServer()
+
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 1, Hash: "block-1"})
+	bs.ScanBlockTask()
+
+	summary := bs.HealthSummary()
+	if !summary.Healthy || summary.Stalled || summary.LocalHeight != 20 || summary.NodeHeight != 20 ||
+		summary.Lag != 0 || summary.LastBlockTime.IsZero() || summary.LastScanTime.IsZero() {
+		t.Errorf("health summary got %+v", summary)
+	}
+
+	//落后节点且扫描高度长时间未推进
+	bs.wm.Config.ScanStallTimeout = time.Millisecond
+	bs.setChainHeight(30)
+	bs.recordNodeHeight(30)
+	time.Sleep(5 * time.Millisecond)
+	summary = bs.HealthSummary()
+	if summary.Healthy || !summary.Stalled || summary.Lag != 10 || len(summary.Problems) == 0 {
+		t.Errorf("stalled health summary got %+v", summary)
+	}
+
+	//扫描停止
+	bs.wm.Config.ScanStallTimeout = time.Hour
+	bs.Scanning = false
+	summary = bs.HealthSummary()
+	if summary.Healthy || summary.Stalled {
+		t.Errorf("stopped health summary got %+v", summary)
+	}
+}
+
+func TestARKBlockScanner_MetricsServer(t *testing.T) {
+	bs, dai, _, closeServer := newTestScanner(newTestChain(10, 1))
+	defer closeServer()
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 1, Hash: "block-1"})
+	bs.ScanBlockTask()
+
+	if err := bs.StartMetricsServer("127.0.0.1:0"); err != nil {
+		t.Fatalf("start metrics server error: %v", err)
+	}
+	defer bs.StopMetricsServer()
+	addr := bs.MetricsAddr()
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/metrics", addr))
+	if err != nil {
+		t.Fatalf("get metrics error: %v", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "ark_scanner_local_height 10\n") {
+		t.Errorf("metrics got %d:\n%s", resp.StatusCode, body)
+	}
+
+	resp, err = http.Get(fmt.Sprintf("http://%s/health", addr))
+	if err != nil {
+		t.Fatalf("get health error: %v", err)
+	}
+	var summary ScannerHealthSummary
+	json.NewDecoder(resp.Body).Decode(&summary)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !summary.Healthy || summary.LocalHeight != 10 {
+		t.Errorf("health got %d %+v", resp.StatusCode, summary)
+	}
+
+	//不健康时返回503
+	bs.Scanning = false
+	recorder := httptest.NewRecorder()
+	bs.MetricsHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("unhealthy status got %d, want 503", recorder.Code)
+	}
+
+	bs.StopMetricsServer()
+	if bs.MetricsAddr() != "" {
+		t.Errorf("metrics server should be stopped")
+	}
+}
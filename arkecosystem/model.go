@@ -15,11 +15,13 @@ type UnscanRecord struct {
 	Reason      string
 }
 
-func NewUnscanRecord(height uint64, txID, reason string) *openwallet.UnscanRecord {
+//NewUnscanRecord 创建未扫记录，ID包含币种，同一数据库中多个链的记录互不覆盖
+func NewUnscanRecord(height uint64, txID, reason, symbol string) *openwallet.UnscanRecord {
 	obj := openwallet.UnscanRecord{}
 	obj.BlockHeight = height
 	obj.TxID = txID
 	obj.Reason = reason
-	obj.ID = common.Bytes2Hex(crypto.SHA256([]byte(fmt.Sprintf("%d_%s", height, txID))))
+	obj.Symbol = symbol
+	obj.ID = common.Bytes2Hex(crypto.SHA256([]byte(fmt.Sprintf("%s_%d_%s", symbol, height, txID))))
 	return &obj
 }
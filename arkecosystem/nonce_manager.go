@@ -0,0 +1,188 @@
+package arkecosystem
+
+import (
+	"fmt"
+	"github.com/blocktree/arkecosystem-adapter/sdk/client"
+	"sync"
+	"time"
+)
+
+const (
+	//默认本地nonce的保留时间
+	defaultNonceExpireTime = 300 * time.Second
+	//交易池每页查询数量
+	poolPageLimit = 100
+)
+
+//localNonce 本地已分配的nonce
+type localNonce struct {
+	TxID      string
+	Submitted bool
+	Expire    time.Time
+}
+
+//accountNonce 单个地址的nonce记录
+type accountNonce struct {
+	mu    sync.Mutex
+	local map[uint64]*localNonce
+}
+
+//NonceManager nonce管理器，结合已确认的钱包nonce、节点交易池及本地已分配的nonce计算下一个可用的nonce
+type NonceManager struct {
+	wm         *WalletManager
+	mu         sync.Mutex
+	accounts   map[string]*accountNonce
+	ExpireTime time.Duration
+}
+
+//NewNonceManager 创建nonce管理器
+func NewNonceManager(wm *WalletManager) *NonceManager {
+	return &NonceManager{
+		wm:         wm,
+		accounts:   make(map[string]*accountNonce),
+		ExpireTime: defaultNonceExpireTime,
+	}
+}
+
+//account 获取地址的nonce记录
+func (nm *NonceManager) account(address string) *accountNonce {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	account, ok := nm.accounts[address]
+	if !ok {
+		account = &accountNonce{local: make(map[uint64]*localNonce)}
+		nm.accounts[address] = account
+	}
+	return account
+}
+
+//Reserve 为地址分配下一个可用的nonce，同一地址的并发调用串行执行
+func (nm *NonceManager) Reserve(address string) (uint64, error) {
+	account := nm.account(address)
+	account.mu.Lock()
+	defer account.mu.Unlock()
+
+	confirmed, err := nm.wm.getConfirmedNonce(address)
+	if err != nil {
+		return 0, err
+	}
+
+	pool, err := nm.wm.getPoolNonces(address)
+	if err != nil {
+		return 0, err
+	}
+
+	nonce := account.next(confirmed, pool, time.Now())
+	account.local[nonce] = &localNonce{Expire: time.Now().Add(nm.ExpireTime)}
+
+	return nonce, nil
+}
+
+//Commit 交易广播成功，记录nonce对应的交易
+func (nm *NonceManager) Commit(address string, nonce uint64, txID string) {
+	account := nm.account(address)
+	account.mu.Lock()
+	defer account.mu.Unlock()
+
+	account.local[nonce] = &localNonce{
+		TxID:      txID,
+		Submitted: true,
+		Expire:    time.Now().Add(nm.ExpireTime),
+	}
+}
+
+//Release 交易创建或广播失败，释放已分配的nonce
+func (nm *NonceManager) Release(address string, nonce uint64) {
+	account := nm.account(address)
+	account.mu.Lock()
+	defer account.mu.Unlock()
+
+	delete(account.local, nonce)
+}
+
+//next 清理过期的本地记录，从已确认nonce开始连续查找第一个未被交易池及本地占用的nonce。
+//交易池中的交易被丢弃后，其nonce不再被占用，后续分配会重新填补该空缺
+func (account *accountNonce) next(confirmed uint64, pool map[uint64]bool, now time.Time) uint64 {
+	for nonce, record := range account.local {
+		if nonce <= confirmed {
+			//已上链
+			delete(account.local, nonce)
+			continue
+		}
+		if pool[nonce] {
+			//仍在交易池中
+			continue
+		}
+		if now.After(record.Expire) {
+			//过期：未广播或已被交易池丢弃
+			delete(account.local, nonce)
+		}
+	}
+
+	nonce := confirmed + 1
+	for {
+		if _, used := account.local[nonce]; !used && !pool[nonce] {
+			return nonce
+		}
+		nonce++
+	}
+}
+
+//getConfirmedNonce 获取地址已确认的nonce
+func (wm *WalletManager) getConfirmedNonce(address string) (uint64, error) {
+	wallet, _, err := wm.Api.Client.Wallets.Get(wm.Context, address)
+	if err == nil && wallet == nil {
+		err = errEmptyNodeResponse
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get wallet [%s] failed, unexpected error: %v", address, err)
+	}
+	return wallet.Data.Nonce, nil
+}
+
+//getPoolTransactions 获取交易池中地址发送的交易
+func (wm *WalletManager) getPoolTransactions(address string) ([]client.Transaction, error) {
+	pool, err := wm.listPoolTransactions()
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]client.Transaction, 0)
+	for _, tx := range pool {
+		if tx.Sender == address {
+			txs = append(txs, tx)
+		}
+	}
+	return txs, nil
+}
+
+//listPoolTransactions 分页获取节点交易池中的全部交易
+func (wm *WalletManager) listPoolTransactions() ([]client.Transaction, error) {
+	txs := make([]client.Transaction, 0)
+	for page := 1; ; page++ {
+		result, _, err := wm.Api.Client.Transactions.ListUnconfirmed(wm.Context, &client.Pagination{Page: page, Limit: poolPageLimit})
+		if err == nil && result == nil {
+			err = errEmptyNodeResponse
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get unconfirmed transactions failed, unexpected error: %v", err)
+		}
+		txs = append(txs, result.Data...)
+		if len(result.Data) < poolPageLimit || page >= int(result.Meta.PageCount) {
+			break
+		}
+	}
+	return txs, nil
+}
+
+//getPoolNonces 获取交易池中地址已占用的nonce
+func (wm *WalletManager) getPoolNonces(address string) (map[uint64]bool, error) {
+	txs, err := wm.getPoolTransactions(address)
+	if err != nil {
+		return nil, err
+	}
+	nonces := make(map[uint64]bool)
+	for _, tx := range txs {
+		nonces[tx.Nonce] = true
+	}
+	return nonces, nil
+}
@@ -0,0 +1,99 @@
+package arkecosystem
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testNonceAddress = "AJWRd23HNEhPLkK1ymMnwnDBX2a7QBZqff"
+
+func testNonceServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc(baseURLPath+"/wallets/"+testNonceAddress, func(writer http.ResponseWriter, request *http.Request) {
+		fmt.Fprintf(writer, `{"data": {"address": "%s", "nonce": "5", "balance": "1000000000"}}`, testNonceAddress)
+	})
+	mux.HandleFunc(baseURLPath+"/transactions/unconfirmed", func(writer http.ResponseWriter, request *http.Request) {
+		fmt.Fprintf(writer,
+			`{
+			  "meta": {"count": 3, "pageCount": 1, "totalCount": 3},
+			  "data": [
+			    {"id": "a", "sender": "%s", "nonce": "6"},
+			    {"id": "b", "sender": "%s", "nonce": "8"},
+			    {"id": "c", "sender": "AUexKjGtgsSpVzPLs6jNMM6vJ6znEVTQWK", "nonce": "7"}
+			  ]
+			}`, testNonceAddress, testNonceAddress)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestNonceManager_Reserve(t *testing.T) {
+	server := testNonceServer()
+	defer server.Close()
+
+	wm := NewWalletManager()
+	wm.Api = NewApi(server.URL)
+
+	count, err := wm.GetAccountPendingTxCount(testNonceAddress)
+	if err != nil || count != 2 {
+		t.Errorf("GetAccountPendingTxCount got %d, %v, want 2", count, err)
+	}
+
+	//并发分配的nonce不重复，且优先填补交易池中的空缺
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		nonces = make(map[uint64]bool)
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonce, err := wm.NonceManager.Reserve(testNonceAddress)
+			if err != nil {
+				t.Errorf("Reserve error: %v", err)
+				return
+			}
+			mu.Lock()
+			nonces[nonce] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for _, want := range []uint64{7, 9, 10, 11} {
+		if !nonces[want] {
+			t.Errorf("Reserve nonces %v, missing %d", nonces, want)
+		}
+	}
+
+	wm.NonceManager.Release(testNonceAddress, 7)
+	nonce, _ := wm.NonceManager.Reserve(testNonceAddress)
+	if nonce != 7 {
+		t.Errorf("Reserve after release got %d, want 7", nonce)
+	}
+}
+
+func TestAccountNonce_Next(t *testing.T) {
+	now := time.Now()
+	account := &accountNonce{local: map[uint64]*localNonce{
+		3: {Submitted: true, Expire: now.Add(time.Minute)},
+		4: {Submitted: true, Expire: now.Add(-time.Minute)},
+		5: {Submitted: true, Expire: now.Add(-time.Minute)},
+	}}
+
+	//4已被交易池丢弃并过期，5仍在交易池中
+	nonce := account.next(3, map[uint64]bool{5: true}, now)
+	if nonce != 4 {
+		t.Errorf("next got %d, want 4", nonce)
+	}
+	if _, ok := account.local[3]; ok {
+		t.Errorf("confirmed nonce should be removed")
+	}
+	if _, ok := account.local[5]; !ok {
+		t.Errorf("pooled nonce should be kept")
+	}
+}
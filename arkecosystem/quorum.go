@@ -0,0 +1,125 @@
+package arkecosystem
+
+import (
+	"fmt"
+	"github.com/blocktree/arkecosystem-adapter/sdk/client"
+	"sync"
+)
+
+//quorumVote 单个节点对某一高度区块的查询结果
+type quorumVote struct {
+	node  *ApiNode
+	block *client.Block
+	err   error
+}
+
+//quorumEnabled 是否启用多节点交叉核对
+func (bs *ARKBlockScanner) quorumEnabled() bool {
+	return bs.wm.Config.BlockQuorum > 1
+}
+
+//quorumNodes 参与交叉核对的节点，按健康状态优先选取。通过peers发现的节点不可信，不参与投票
+func (bs *ARKBlockScanner) quorumNodes() []*ApiNode {
+	nodes := configuredNodes(bs.wm.Api.Nodes())
+	limit := bs.wm.Config.BlockQuorumNodes
+	if limit > 0 && limit < len(nodes) {
+		nodes = nodes[:limit]
+	}
+	return nodes
+}
+
+//getQuorumBlockByHeight 向多个节点查询同一高度的区块，达到一致的节点数后才接受该区块，
+//多个不同区块同时达到一致的节点数时不接受任何区块，节点返回不一致时记录健康事件
+func (bs *ARKBlockScanner) getQuorumBlockByHeight(height uint64) (*client.Block, error) {
+
+	quorum := bs.wm.Config.BlockQuorum
+	nodes := bs.quorumNodes()
+	if len(nodes) < quorum {
+		return nil, fmt.Errorf("block quorum %d is greater than the number of api nodes %d", quorum, len(nodes))
+	}
+
+	votes := make([]quorumVote, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node *ApiNode) {
+			defer wg.Done()
+			block, err := listBlockByHeight(node.Client, height)
+			votes[i] = quorumVote{node: node, block: block, err: err}
+		}(i, node)
+	}
+	wg.Wait()
+
+	//按区块ID及上一区块ID分组计票
+	type ballot struct {
+		block *client.Block
+		count int
+	}
+	ballots := make(map[string]*ballot)
+	results := make(map[string]string)
+	var best *ballot
+	for _, vote := range votes {
+		host := vote.node.URL.Host
+		if vote.err != nil {
+			results[host] = "error: " + vote.err.Error()
+			continue
+		}
+		results[host] = vote.block.Id
+		key := vote.block.Id + ":" + vote.block.Previous
+		b := ballots[key]
+		if b == nil {
+			b = &ballot{block: vote.block}
+			ballots[key] = b
+		}
+		b.count++
+		if best == nil || b.count > best.count {
+			best = b
+		}
+	}
+
+	if len(ballots) > 1 {
+		bs.healthNotify(&ScannerHealthEvent{
+			Type:    HealthEventQuorumDisagreement,
+			Height:  height,
+			Message: fmt.Sprintf("api nodes returned %d different blocks", len(ballots)),
+			Nodes:   results,
+		})
+	}
+
+	if best == nil || best.count < quorum {
+		agreed := 0
+		if best != nil {
+			agreed = best.count
+		}
+		err := fmt.Errorf("block quorum not reached on height %d, %d of %d nodes agreed, %d required",
+			height, agreed, len(nodes), quorum)
+		bs.healthNotify(&ScannerHealthEvent{
+			Type:    HealthEventQuorumFailed,
+			Height:  height,
+			Message: err.Error(),
+			Nodes:   results,
+		})
+		return nil, err
+	}
+
+	//一致的节点数不超过半数时，不同区块可能同时达到要求
+	reached := 0
+	for _, b := range ballots {
+		if b.count >= quorum {
+			reached++
+		}
+	}
+	if reached > 1 {
+		err := fmt.Errorf("block quorum conflicts on height %d, %d different blocks are agreed by %d nodes",
+			height, reached, quorum)
+		bs.healthNotify(&ScannerHealthEvent{
+			Type:    HealthEventQuorumFailed,
+			Height:  height,
+			Message: err.Error(),
+			Nodes:   results,
+		})
+		return nil, err
+	}
+
+	return best.block, nil
+}
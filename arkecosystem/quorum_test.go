@@ -0,0 +1,140 @@
+package arkecosystem
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blocktree/openwallet/v2/openwallet"
+)
+
+//testHealthObserver 记录健康事件
+type testHealthObserver struct {
+	testObserver
+	events []*ScannerHealthEvent
+}
+
+func (o *testHealthObserver) ScannerHealthNotify(event *ScannerHealthEvent) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, event)
+	return nil
+}
+
+func (o *testHealthObserver) healthEvents() []*ScannerHealthEvent {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]*ScannerHealthEvent{}, o.events...)
+}
+
+//newQuorumTestScanner 两个诚实节点及一个从第15个区块开始返回伪造区块的节点
+func newQuorumTestScanner(quorum int) (*ARKBlockScanner, *testBlockchainDAI, *testHealthObserver, func()) {
+	honest := newTestChain(20, 1)
+	forged := newTestChain(20, 1)
+	forged.reorg(15, 20, 1)
+
+	bs, dai, _, closeServer := newTestScanner(honest)
+	servers := []*httptest.Server{honest.server(), honest.server(), forged.server()}
+	bs.wm.Api = NewApi(servers[0].URL + "," + servers[1].URL + "," + servers[2].URL)
+	bs.wm.Config.BlockQuorum = quorum
+
+	observer := &testHealthObserver{}
+	bs.AddObserver(observer)
+
+	return bs, dai, observer, func() {
+		closeServer()
+		for _, server := range servers {
+			server.Close()
+		}
+	}
+}
+
+func TestARKBlockScanner_ScanBlockTaskQuorum(t *testing.T) {
+	bs, dai, observer, closeServer := newQuorumTestScanner(2)
+	defer closeServer()
+
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 1, Hash: "block-1"})
+	bs.ScanBlockTask()
+
+	if dai.head.Height != 20 || dai.head.Hash != "block-20" {
+		t.Errorf("scanned head got %d %s, want block-20", dai.head.Height, dai.head.Hash)
+	}
+	events := observer.healthEvents()
+	if len(events) != 6 {
+		t.Fatalf("got %d health events, want 6", len(events))
+	}
+	//流水线并行获取区块，事件顺序不固定
+	heights := make(map[uint64]bool)
+	for _, event := range events {
+		if event.Type != HealthEventQuorumDisagreement || event.Height < 15 || len(event.Nodes) != 3 {
+			t.Errorf("health event got %s on %d", event.Type, event.Height)
+		}
+		heights[event.Height] = true
+	}
+	if len(heights) != 6 {
+		t.Errorf("health events reported %d heights, want 6", len(heights))
+	}
+	if len(bs.HealthEvents()) != 6 {
+		t.Errorf("scanner kept %d health events, want 6", len(bs.HealthEvents()))
+	}
+}
+
+func TestARKBlockScanner_ScanBlockTaskQuorumFailed(t *testing.T) {
+	bs, dai, observer, closeServer := newQuorumTestScanner(3)
+	defer closeServer()
+
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 1, Hash: "block-1"})
+	bs.ScanBlockTask()
+
+	if dai.head.Height != 14 {
+		t.Errorf("scanned head got %d, want 14", dai.head.Height)
+	}
+	failed := 0
+	for _, event := range observer.healthEvents() {
+		if event.Type == HealthEventQuorumFailed {
+			failed++
+		}
+	}
+	if failed == 0 {
+		t.Errorf("quorum failure should be reported as health event")
+	}
+	if len(dai.unscan) == 0 {
+		t.Errorf("block without quorum should be recorded for rescan")
+	}
+}
+
+func TestARKBlockScanner_QuorumConflict(t *testing.T) {
+	honest := newTestChain(20, 1)
+	forged := newTestChain(20, 1)
+	forged.reorg(15, 20, 1)
+
+	bs, _, _, closeServer := newTestScanner(honest)
+	defer closeServer()
+	servers := []*httptest.Server{honest.server(), honest.server(), forged.server(), forged.server()}
+	defer func() {
+		for _, server := range servers {
+			server.Close()
+		}
+	}()
+	bs.wm.Api = NewApi(servers[0].URL + "," + servers[1].URL + "," + servers[2].URL)
+	bs.wm.Config.BlockQuorum = 2
+
+	//通过peers发现的节点不参与投票
+	bs.wm.Api.mu.Lock()
+	bs.wm.Api.addNode(servers[3].URL, true)
+	bs.wm.Api.mu.Unlock()
+	if nodes := bs.quorumNodes(); len(nodes) != 3 {
+		t.Fatalf("quorum nodes got %d, want 3", len(nodes))
+	}
+	if block, err := bs.getQuorumBlockByHeight(15); err != nil || block.Id != "block-15" {
+		t.Errorf("quorum block got %v, %v", block, err)
+	}
+
+	//两个不同区块同时达到一致的节点数
+	bs.wm.Api = NewApi(servers[0].URL + "," + servers[1].URL + "," + servers[2].URL + "," + servers[3].URL)
+	if block, err := bs.getQuorumBlockByHeight(15); err == nil {
+		t.Errorf("conflicting quorum should fail, got %s", block.Id)
+	}
+	if block, err := bs.getQuorumBlockByHeight(14); err != nil || block.Id != "block-14" {
+		t.Errorf("agreed quorum block got %v, %v", block, err)
+	}
+}
@@ -0,0 +1,181 @@
+package arkecosystem
+
+import (
+	"fmt"
+	"github.com/blocktree/arkecosystem-adapter/sdk/client"
+	"github.com/blocktree/openwallet/v2/openwallet"
+	"sync"
+)
+
+const (
+	//默认分叉时向前查找共同祖先区块的最大深度
+	defaultMaxReorgDepth = 100
+)
+
+//BlockForkObserver 分叉区块观测者，观测者实现该接口后，每个被回滚的区块都会连同此前已通知的交易ID一起通知
+type BlockForkObserver interface {
+	//BlockForkNotify 区块被回滚，txIDs为该区块此前通知过的交易
+	BlockForkNotify(header *openwallet.BlockHeader, txIDs []string) error
+}
+
+//reportedBlock 区块已通知的交易
+type reportedBlock struct {
+	Hash  string
+	TxIDs []string
+}
+
+//reportedTxCache 缓存近期区块已通知的交易ID，用于分叉回滚
+type reportedTxCache struct {
+	mu     sync.Mutex
+	blocks map[uint64]*reportedBlock
+}
+
+func newReportedTxCache() *reportedTxCache {
+	return &reportedTxCache{blocks: make(map[uint64]*reportedBlock)}
+}
+
+//add 记录区块已通知的交易，并清理超出回滚深度的记录，返回该区块全部已通知的交易ID
+func (c *reportedTxCache) add(block *client.Block, extractData []*ExtractTxResult, depth uint64) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	height := uint64(block.Height)
+	reported := c.blocks[height]
+	if reported == nil || reported.Hash != block.Id {
+		reported = &reportedBlock{Hash: block.Id}
+	}
+	for _, tx := range extractData {
+		if len(tx.extractData) == 0 || containsString(reported.TxIDs, tx.TxID) {
+			continue
+		}
+		reported.TxIDs = append(reported.TxIDs, tx.TxID)
+	}
+	if len(reported.TxIDs) > 0 {
+		c.blocks[height] = reported
+	}
+
+	for h := range c.blocks {
+		if h+depth < height {
+			delete(c.blocks, h)
+		}
+	}
+	return append([]string{}, reported.TxIDs...)
+}
+
+//remove 取出并删除区块已通知的交易
+func (c *reportedTxCache) remove(height uint64, hash string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reported := c.blocks[height]
+	if reported == nil || reported.Hash != hash {
+		return []string{}
+	}
+	delete(c.blocks, height)
+	return reported.TxIDs
+}
+
+//forkRollback 区块分叉时向前查找共同祖先区块，通知所有被回滚的区块，返回新的扫描高度及hash
+func (bs *ARKBlockScanner) forkRollback(height uint64, localHash string, block *client.Block) (uint64, string, error) {
+
+	bs.wm.Log.Std.Info("block has been fork on height: %d.", height)
+	bs.wm.Log.Std.Info("block height: %d local hash = %s ", height-1, localHash)
+	bs.wm.Log.Std.Info("block height: %d mainnet hash = %s ", height-1, block.Previous)
+
+	if m := bs.Metrics(); m != nil {
+		m.AddCounter(MetricForks, nil, 1)
+	}
+
+	ancestor, orphaned, err := bs.findCommonAncestor(height-1, localHash)
+	if err != nil {
+		bs.wm.Log.Std.Error("block scanner can not find common ancestor; unexpected error: %v", err)
+		return height - 1, localHash, err
+	}
+
+	bs.wm.Log.Std.Info("rescan block on height: %d, hash: %s .", ancestor.Height, ancestor.Id)
+
+	//重新记录一个新扫描起点
+	bs.SaveLocalBlockHead(uint64(ancestor.Height), ancestor.Id)
+
+	for _, forkBlock := range orphaned {
+		bs.wm.Log.Std.Info("delete recharge records on block height: %d.", forkBlock.Height)
+
+		//删除分叉区块的未扫记录
+		bs.DeleteUnscanRecord(uint64(forkBlock.Height))
+
+		//删除本地区块库中的分叉区块及交易
+		err = bs.deleteStoredBlock(uint64(forkBlock.Height), forkBlock.Id)
+		if err != nil {
+			bs.wm.Log.Std.Warning("block scanner can not delete stored block %d; unexpected error: %v", forkBlock.Height, err)
+		}
+
+		//通知分叉区块给观测者
+		bs.forkBlockNotify(forkBlock)
+	}
+
+	return uint64(ancestor.Height), ancestor.Id, nil
+}
+
+//findCommonAncestor 从指定高度开始逐个对比本地区块与节点区块，返回共同祖先区块及被回滚的区块（高度从高到低）
+func (bs *ARKBlockScanner) findCommonAncestor(height uint64, hash string) (*client.Block, []*client.Block, error) {
+
+	orphaned := make([]*client.Block, 0)
+	for depth := uint64(0); ; depth++ {
+
+		if height == 0 {
+			return nil, nil, fmt.Errorf("common ancestor not found before genesis block")
+		}
+
+		if depth >= bs.wm.Config.MaxReorgDepth {
+			return nil, nil, fmt.Errorf("common ancestor not found within %d blocks", bs.wm.Config.MaxReorgDepth)
+		}
+
+		remoteBlock, err := bs.getBlockByHeight(height)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		localBlock, err := bs.GetLocalBlock(height)
+		if depth == 0 && (err != nil || localBlock.Id != hash) {
+			//本地扫描起点可能未保存区块头
+			localBlock, err = &client.Block{Id: hash, Height: int64(height)}, nil
+		}
+		if err != nil {
+			//本地区块头已被清理，无法确认共同祖先，停止回滚
+			return nil, nil, fmt.Errorf("local block %d not found, can not compare with the node: %v", height, err)
+		}
+
+		if localBlock.Id == remoteBlock.Id {
+			return remoteBlock, orphaned, nil
+		}
+
+		orphaned = append(orphaned, localBlock)
+		height--
+	}
+}
+
+//forkBlockNotify 通知分叉区块及其已通知的交易ID
+func (bs *ARKBlockScanner) forkBlockNotify(block *client.Block) {
+
+	header := block.BlockHeader(bs.wm.Symbol())
+	header.Fork = true
+	txIDs := bs.reportedTxs.remove(header.Height, header.Hash)
+	//重启后内存中没有记录，从本地区块库读取
+	if stored := bs.takeReportedTxs(header.Height, header.Hash); len(txIDs) == 0 && stored != nil {
+		txIDs = stored
+	}
+
+	for o, _ := range bs.Observers {
+		forkObserver, ok := o.(BlockForkObserver)
+		if !ok {
+			continue
+		}
+		err := forkObserver.BlockForkNotify(header, txIDs)
+		if err != nil {
+			bs.wm.Log.Error("BlockForkNotify unexpected error:", err)
+		}
+	}
+
+	//通知分叉区块给观测者，异步处理
+	bs.NewBlockNotify(header)
+}
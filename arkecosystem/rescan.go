@@ -0,0 +1,179 @@
+package arkecosystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+//RescanProgress 后台重扫任务的进度
+type RescanProgress struct {
+	From          uint64
+	To            uint64
+	Current       uint64        //最近通知完成的高度
+	Scanned       uint64        //已处理的区块数
+	Total         uint64        //需处理的区块数
+	FailedHeights []uint64      //获取或提取失败的高度
+	StartTime     time.Time     //开始时间
+	ETA           time.Duration //按已用时间估算的剩余时间
+	Done          bool
+	Canceled      bool
+}
+
+//RescanJob 后台重扫任务，与实时扫描并行，不移动扫描高度。
+//通知给观测者的交易在Transaction.ExtParam中带有"rescan": true
+type RescanJob struct {
+	mu       sync.Mutex
+	progress RescanProgress
+	quit     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+//Progress 当前进度
+func (job *RescanJob) Progress() RescanProgress {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	progress := job.progress
+	progress.FailedHeights = append([]uint64{}, job.progress.FailedHeights...)
+	if !progress.Done && progress.Scanned > 0 {
+		elapsed := time.Since(progress.StartTime)
+		progress.ETA = elapsed / time.Duration(progress.Scanned) * time.Duration(progress.Total-progress.Scanned)
+	}
+	return progress
+}
+
+//Cancel 取消任务，已提取的区块不再通知
+func (job *RescanJob) Cancel() {
+	job.once.Do(func() {
+		close(job.quit)
+	})
+}
+
+//Done 任务结束时关闭
+func (job *RescanJob) Done() <-chan struct{} {
+	return job.done
+}
+
+//RescanRange 在后台重扫[from, to]高度范围的区块并通知观测者，concurrency为并行获取及提取的区块数，0使用scanConcurrency。
+//to不超过达到确认数的高度，同一时间只能运行一个重扫任务
+func (bs *ARKBlockScanner) RescanRange(from, to uint64, concurrency int) (*RescanJob, error) {
+
+	if bs.ScanTargetFunc == nil {
+		return nil, fmt.Errorf("scan target func is not set")
+	}
+
+	tip, err := bs.getCurrentBlock()
+	if err != nil {
+		return nil, err
+	}
+	if maxHeight := bs.confirmedHeight(uint64(tip.Height)); to > maxHeight {
+		to = maxHeight
+	}
+	if from == 0 || from > to {
+		return nil, fmt.Errorf("invalid rescan range: %d - %d", from, to)
+	}
+
+	if concurrency <= 0 {
+		concurrency = bs.wm.Config.ScanConcurrency
+	}
+
+	job := &RescanJob{
+		progress: RescanProgress{From: from, To: to, Total: to - from + 1, StartTime: time.Now()},
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	bs.rescanMu.Lock()
+	defer bs.rescanMu.Unlock()
+	if bs.rescanJob != nil && !bs.rescanJob.Progress().Done {
+		return nil, fmt.Errorf("rescan job %d - %d is running", bs.rescanJob.progress.From, bs.rescanJob.progress.To)
+	}
+	bs.rescanJob = job
+
+	go bs.runRescanJob(job, make(chan struct{}, concurrency))
+
+	bs.wm.Log.Std.Info("block scanner start rescanning height: %d - %d", from, to)
+	return job, nil
+}
+
+//RescanJob 最近一次的后台重扫任务，没有时返回nil
+func (bs *ARKBlockScanner) RescanJob() *RescanJob {
+	bs.rescanMu.Lock()
+	defer bs.rescanMu.Unlock()
+	return bs.rescanJob
+}
+
+//runRescanJob 按高度顺序通知重扫结果
+func (bs *ARKBlockScanner) runRescanJob(job *RescanJob, tokens chan struct{}) {
+
+	defer close(job.done)
+
+	ordered := bs.blockPipeline(job.progress.From, job.progress.To, tokens, job.quit)
+	canceled := false
+	for resultCH := range ordered {
+
+		result := <-resultCH
+
+		select {
+		case <-job.quit:
+			canceled = true
+		default:
+		}
+		if canceled {
+			break
+		}
+
+		failed := result.FetchErr != nil || result.ExtractErr != nil || !result.Extract.Success || len(result.Extract.failedTxs) > 0
+		if result.FetchErr == nil && result.ExtractErr == nil && result.Extract.Success {
+			markRescanResults(result.Extract.extractData)
+			bs.newExtractDataNotify(result.Height, result.Extract.extractData)
+		}
+		if failed {
+			bs.wm.Log.Std.Warning("block scanner rescan height: %d failed", result.Height)
+		}
+
+		job.mu.Lock()
+		job.progress.Current = result.Height
+		job.progress.Scanned++
+		if failed {
+			job.progress.FailedHeights = append(job.progress.FailedHeights, result.Height)
+		}
+		job.mu.Unlock()
+	}
+
+	//通道关闭前也可能已取消
+	select {
+	case <-job.quit:
+		canceled = true
+	default:
+	}
+
+	job.mu.Lock()
+	job.progress.Done = true
+	job.progress.Canceled = canceled
+	job.progress.ETA = 0
+	job.mu.Unlock()
+
+	bs.wm.Log.Std.Info("block scanner rescanning height: %d - %d finished, current: %d, canceled: %v",
+		job.progress.From, job.progress.To, job.progress.Current, canceled)
+}
+
+//markRescanResults 在交易的ExtParam中标记为重扫结果
+func markRescanResults(results []*ExtractTxResult) {
+	for _, result := range results {
+		for _, data := range result.extractData {
+			if data == nil || data.Transaction == nil {
+				continue
+			}
+			ext := make(map[string]interface{})
+			if len(data.Transaction.ExtParam) > 0 {
+				json.Unmarshal([]byte(data.Transaction.ExtParam), &ext)
+			}
+			ext["rescan"] = true
+			extParam, _ := json.Marshal(ext)
+			data.Transaction.ExtParam = string(extParam)
+		}
+	}
+}
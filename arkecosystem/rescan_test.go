@@ -0,0 +1,125 @@
+package arkecosystem
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blocktree/openwallet/v2/openwallet"
+)
+
+//testBlockingObserver 第一次通知时阻塞，直到release关闭
+type testBlockingObserver struct {
+	testObserver
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func (o *testBlockingObserver) BlockExtractDataNotify(sourceKey string, data *openwallet.TxExtractData) error {
+	o.once.Do(func() {
+		close(o.started)
+		<-o.release
+	})
+	return o.testObserver.BlockExtractDataNotify(sourceKey, data)
+}
+
+func waitRescanJob(t *testing.T, job *RescanJob) RescanProgress {
+	select {
+	case <-job.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("rescan job is not finished")
+	}
+	return job.Progress()
+}
+
+func TestARKBlockScanner_RescanRange(t *testing.T) {
+	bs, dai, observer, closeServer := newTestScanner(newTestChain(30, 1))
+	defer closeServer()
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 30, Hash: "block-30"})
+
+	if _, err := bs.RescanRange(0, 10, 2); err == nil {
+		t.Errorf("rescan from height 0 should fail")
+	}
+	if _, err := bs.RescanRange(25, 10, 2); err == nil {
+		t.Errorf("rescan with from > to should fail")
+	}
+
+	job, err := bs.RescanRange(5, 20, 2)
+	if err != nil {
+		t.Fatalf("rescan range error: %v", err)
+	}
+	progress := waitRescanJob(t, job)
+	if !progress.Done || progress.Canceled || progress.Current != 20 || progress.Scanned != 16 ||
+		progress.Total != 16 || len(progress.FailedHeights) != 0 || progress.ETA != 0 {
+		t.Errorf("rescan progress got %+v", progress)
+	}
+	if bs.RescanJob() != job {
+		t.Errorf("rescan job should be kept by the scanner")
+	}
+
+	//重扫不移动扫描高度
+	if dai.head.Height != 30 || dai.head.Hash != "block-30" {
+		t.Errorf("scan head got %d %s, want block-30", dai.head.Height, dai.head.Hash)
+	}
+
+	observer.mu.Lock()
+	if len(observer.data) != 16 {
+		t.Fatalf("notified %d transactions, want 16", len(observer.data))
+	}
+	for i, data := range observer.data {
+		ext := make(map[string]interface{})
+		json.Unmarshal([]byte(data.Transaction.ExtParam), &ext)
+		if ext["rescan"] != true || ext["action"] != TxActionTransfer {
+			t.Errorf("ext param got %s", data.Transaction.ExtParam)
+		}
+		if observer.heights[i] != uint64(i+5) {
+			t.Errorf("notify order got height %d at %d, want %d", observer.heights[i], i, i+5)
+		}
+	}
+	observer.mu.Unlock()
+
+	//结束高度不超过链高度
+	job, err = bs.RescanRange(25, 100, 0)
+	if err != nil {
+		t.Fatalf("rescan range error: %v", err)
+	}
+	if progress := waitRescanJob(t, job); progress.To != 30 || progress.Scanned != 6 {
+		t.Errorf("rescan progress got %+v, want 25 - 30", progress)
+	}
+}
+
+func TestARKBlockScanner_RescanRangeCancel(t *testing.T) {
+	bs, _, _, closeServer := newTestScanner(newTestChain(100, 1))
+	defer closeServer()
+
+	observer := &testBlockingObserver{started: make(chan struct{}), release: make(chan struct{})}
+	bs.AddObserver(observer)
+
+	job, err := bs.RescanRange(1, 100, 2)
+	if err != nil {
+		t.Fatalf("rescan range error: %v", err)
+	}
+
+	select {
+	case <-observer.started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("rescan job is not started")
+	}
+	if _, err := bs.RescanRange(1, 10, 2); err == nil {
+		t.Errorf("only one rescan job can run at a time")
+	}
+	if progress := job.Progress(); progress.Done || progress.Total != 100 {
+		t.Errorf("running rescan progress got %+v", progress)
+	}
+
+	job.Cancel()
+	job.Cancel()
+	close(observer.release)
+
+	progress := waitRescanJob(t, job)
+	if !progress.Done || !progress.Canceled || progress.Scanned >= 100 {
+		t.Errorf("canceled rescan progress got %+v", progress)
+	}
+}
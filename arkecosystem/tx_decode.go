@@ -1,17 +1,14 @@
 package arkecosystem
 
 import (
-	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/blocktree/arkecosystem-adapter/arkecosystem_txsigner"
-	"github.com/blocktree/arkecosystem-adapter/sdk/client"
 	"github.com/blocktree/arkecosystem-adapter/sdk/crypto"
 	"github.com/blocktree/go-owcrypt"
 	"github.com/blocktree/openwallet/v2/common"
-	"github.com/blocktree/openwallet/v2/log"
 	"github.com/blocktree/openwallet/v2/openwallet"
 	"github.com/shopspring/decimal"
 	"math/big"
@@ -84,7 +81,7 @@ func (decoder *TransactionDecoder) CreateRawTransaction(wrapper openwallet.Walle
 	if len(rawTx.FeeRate) > 0 {
 		fixFees = common.StringNumToBigIntWithExp(rawTx.FeeRate, decimals)
 	} else {
-		fixFees = common.StringNumToBigIntWithExp(decoder.wm.Config.FixFees, decimals)
+		fixFees = common.StringNumToBigIntWithExp(decoder.wm.transferFees(), decimals)
 	}
 
 	for _, addrBalance := range addrBalanceArray {
@@ -169,61 +166,23 @@ func (decoder *TransactionDecoder) createRawTransaction(
 		return err
 	}
 
-	//decoder.wm.Log.Debugf("nonce: %d", nonce)
-	//decoder.wm.Log.Debugf("pending: %d", pending)
-
 	amount := common.StringNumToBigIntWithExp(amountStr, decimals)
 
-	//senderPk, err := hex.DecodeString(addr.PublicKey)
-	//if err != nil {
-	//	return err
-	//}
-
-	addressWallet, _, err := decoder.wm.Api.Client.Wallets.Get(context.Background(), addr.Address)
-
-	if err != nil {
-		return err
-	}
+	//未指定nonce时由nonce管理器分配，创建失败则释放
 	if nonce == 0 {
-
-		nonce = addressWallet.Data.Nonce
-	}
-
-	//wrapper.SetAddressExtParam(addr.Address,"nonce",common.NewStringByUInt(69))
-	//获取db记录的nonce并确认nonce值
-	//nonce_db, ok := decoder.wm.Config.NonceMap[addr.Address]
-	//if ok && nonce != 0 {
-	//	nonce = nonce_db
-	//} else {
-	//wrapper.SetAddressExtParam(addr.Address, "nonce",nil)
-
-	nonceParamter, err := wrapper.GetAddressExtParam(addr.Address, "nonceNew1")
-
-	nonceNewTime, err2 := wrapper.GetAddressExtParam(addr.Address, "nonceNewTime")
-	if err != nil || nonceParamter == nil || nonceNewTime == nil || err2 != nil {
-		nonce = addressWallet.Data.Nonce
-	} else {
-
-		nonceTemp, err := strconv.Atoi(nonceParamter.(string))
-		if err == nil {
-			nonceNewTimeTemp, err := strconv.Atoi(nonceNewTime.(string))
-			if err == nil && time.Now().Unix()- int64(nonceNewTimeTemp) < 300 { //300秒以内不刷新
-				nonce = uint64(nonceTemp)
-			}
-
-		} else {
-			nonce = addressWallet.Data.Nonce
+		nonce, err = decoder.wm.NonceManager.Reserve(addr.Address)
+		if err != nil {
+			return err
 		}
+		defer func() {
+			if err != nil {
+				decoder.wm.NonceManager.Release(addr.Address, nonce)
+			}
+		}()
 	}
 
-	if nonce == 0 {
-		nonce = addressWallet.Data.Nonce
-	}
-	//}
-
-	transaction := crypto.BuildTransferMySelf(destination, crypto.FlexToshi(amount.Uint64()), addr.PublicKey, addr.Address, nonce)
-
-	decoder.wm.Config.NonceMap[transaction.SenderId] = transaction.Nonce
+	transaction := decoder.wm.Config.Crypto.BuildTransferMySelf(destination, crypto.FlexToshi(amount.Uint64()), addr.PublicKey, addr.Address, nonce)
+	transaction.Fee = crypto.FlexToshi(feeInfo.Uint64())
 
 	txRaw, err := transaction.ToJson()
 	if err != nil {
@@ -233,7 +192,7 @@ func (decoder *TransactionDecoder) createRawTransaction(
 	rawTx.RawHex = string(txRaw)
 
 	bytes := sha256.New()
-	_, err = bytes.Write(transaction.Serialize(false, false, false))
+	_, err = bytes.Write(decoder.wm.Config.Crypto.Serialize(transaction, false, false, false))
 	if err != nil {
 		return err
 	}
@@ -379,43 +338,19 @@ func (decoder *TransactionDecoder) SubmitRawTransaction(wrapper openwallet.Walle
 		return nil, fmt.Errorf("serializableTransaction decode failed, unexpected error: %v", err)
 	}
 
-	rawTx.TxID = serializableTransaction.GetId()
-	serializableTransaction.Id = rawTx.TxID
-	trans := make([]client.Transaction2, 0)
-	clientTransaction := client.Transaction2{
-		//Id:              serializableTransaction.Id,
-		Version:         uint16(serializableTransaction.Version),
-		TypeGroup:       1,
-		Type:            uint16(serializableTransaction.Type),
-		Amount:          uint64(serializableTransaction.Amount),
-		Fee:             uint64(serializableTransaction.Fee),
-		SenderPublicKey: serializableTransaction.SenderPublicKey,
-		RecipientId:     serializableTransaction.RecipientId,
-		Signature:       serializableTransaction.Signature,
-		Nonce:           serializableTransaction.Nonce,
-	}
-
-	clientTransaction.Id = rawTx.TxID
-
-	trans = append(trans, clientTransaction)
-	body := &client.CreateTransactionRequest{
-		Transactions: trans,
-	}
-
-	responseStruct, _, err := decoder.wm.Api.Client.Transactions.Create(context.Background(), body)
-
+	txID, err := decoder.wm.submitTransaction(&serializableTransaction)
 	if err != nil {
+		decoder.wm.NonceManager.Release(serializableTransaction.SenderId, serializableTransaction.Nonce)
 		return nil, err
 	}
 
-	log.Infof("Transaction [%s] submitted to the network successfully.", responseStruct.Accept)
+	decoder.wm.NonceManager.Commit(serializableTransaction.SenderId, serializableTransaction.Nonce, txID)
 
+	rawTx.TxID = txID
 	rawTx.IsSubmit = true
 
 	decimals := decoder.wm.Decimal()
 
-	wrapper.SetAddressExtParam(serializableTransaction.SenderId, "nonceNew1", common.NewStringByUInt(serializableTransaction.Nonce))
-	wrapper.SetAddressExtParam(serializableTransaction.SenderId, "nonceNewTime", common.NewStringByInt(time.Now().Unix()))
 	//记录一个交易单
 	tx := &openwallet.Transaction{
 		From:       rawTx.TxFrom,
@@ -436,7 +371,7 @@ func (decoder *TransactionDecoder) SubmitRawTransaction(wrapper openwallet.Walle
 
 //GetRawTransactionFeeRate 获取交易单的费率
 func (decoder *TransactionDecoder) GetRawTransactionFeeRate() (feeRate string, unit string, err error) {
-	return "0.1", "ARK", nil
+	return decoder.wm.transferFees(), decoder.wm.Symbol(), nil
 }
 
 //CreateSummaryRawTransaction 创建汇总交易
@@ -480,7 +415,7 @@ func (decoder *TransactionDecoder) CreateSummaryRawTransaction(wrapper openwalle
 	if len(sumRawTx.FeeRate) > 0 {
 		fixFees = common.StringNumToBigIntWithExp(sumRawTx.FeeRate, decimals)
 	} else {
-		fixFees = common.StringNumToBigIntWithExp(decoder.wm.Config.FixFees, decimals)
+		fixFees = common.StringNumToBigIntWithExp(decoder.wm.transferFees(), decimals)
 	}
 
 
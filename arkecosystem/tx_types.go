@@ -0,0 +1,192 @@
+package arkecosystem
+
+import (
+	"fmt"
+	"github.com/blocktree/arkecosystem-adapter/sdk/client"
+	"github.com/blocktree/arkecosystem-adapter/sdk/crypto"
+)
+
+//交易动作，记录在Transaction.TxAction及ExtParam的action中
+const (
+	TxActionTransfer             = "transfer"
+	TxActionSecondSignature      = "secondSignature"
+	TxActionDelegateRegistration = "delegateRegistration"
+	TxActionVote                 = "vote"
+	TxActionMultiSignature       = "multiSignature"
+	TxActionIpfs                 = "ipfs"
+	TxActionMultiPayment         = "multiPayment"
+	TxActionDelegateResignation  = "delegateResignation"
+	TxActionHtlcLock             = "htlcLock"
+	TxActionHtlcClaim            = "htlcClaim"
+	TxActionHtlcRefund           = "htlcRefund"
+	TxActionUnknown              = "unknown"
+)
+
+//coreTxActions 核心交易类型对应的动作
+var coreTxActions = map[uint16]string{
+	crypto.TRANSACTION_TYPES.Transfer:                    TxActionTransfer,
+	crypto.TRANSACTION_TYPES.SecondSignatureRegistration: TxActionSecondSignature,
+	crypto.TRANSACTION_TYPES.DelegateRegistration:        TxActionDelegateRegistration,
+	crypto.TRANSACTION_TYPES.Vote:                        TxActionVote,
+	crypto.TRANSACTION_TYPES.MultiSignatureRegistration:  TxActionMultiSignature,
+	crypto.TRANSACTION_TYPES.Ipfs:                        TxActionIpfs,
+	crypto.TRANSACTION_TYPES.MultiPayment:                TxActionMultiPayment,
+	crypto.TRANSACTION_TYPES.DelegateResignation:         TxActionDelegateResignation,
+	crypto.TRANSACTION_TYPES.HtlcLock:                    TxActionHtlcLock,
+	crypto.TRANSACTION_TYPES.HtlcClaim:                   TxActionHtlcClaim,
+	crypto.TRANSACTION_TYPES.HtlcRefund:                  TxActionHtlcRefund,
+}
+
+//transactionAction 根据Type及TypeGroup判断交易动作，v1交易没有typeGroup
+func transactionAction(trans *client.Transaction) string {
+	typeGroup := uint32(trans.TypeGroup)
+	if typeGroup != 0 && typeGroup != crypto.TRANSACTION_TYPE_GROUPS.Core {
+		return TxActionUnknown
+	}
+	action, ok := coreTxActions[uint16(trans.Type)]
+	if !ok {
+		return TxActionUnknown
+	}
+	return action
+}
+
+//isValueAction 交易动作是否转移金额，其余交易只扣除手续费
+func isValueAction(action string) bool {
+	switch action {
+	case TxActionTransfer, TxActionMultiPayment, TxActionHtlcLock:
+		return true
+	}
+	return false
+}
+
+//transactionPayments 交易的收款列表，多笔转账展开为每个收款人，不转移金额的交易没有收款
+func transactionPayments(trans *client.Transaction) []*client.MultiPaymentAsset {
+	action := transactionAction(trans)
+	if !isValueAction(action) {
+		return []*client.MultiPaymentAsset{}
+	}
+	if action == TxActionMultiPayment {
+		if trans.Asset == nil {
+			return []*client.MultiPaymentAsset{}
+		}
+		return trans.Asset.Payments
+	}
+	if len(trans.Recipient) == 0 {
+		return []*client.MultiPaymentAsset{}
+	}
+	return []*client.MultiPaymentAsset{{Amount: trans.Amount, RecipientId: trans.Recipient}}
+}
+
+//transactionExtParam 交易的类型参数，记录到Transaction.ExtParam
+func transactionExtParam(trans *client.Transaction, action string) map[string]interface{} {
+	ext := map[string]interface{}{
+		"action":    action,
+		"type":      trans.Type,
+		"typeGroup": trans.TypeGroup,
+	}
+
+	if memo, memoHex := transactionMemo(trans); len(memoHex) > 0 {
+		ext["vendorField"] = memo
+		ext["vendorFieldHex"] = memoHex
+	}
+
+	asset := trans.Asset
+	if asset == nil {
+		return ext
+	}
+
+	switch action {
+	case TxActionVote:
+		ext["votes"] = asset.Votes
+	case TxActionDelegateRegistration:
+		if asset.Delegate != nil {
+			ext["username"] = asset.Delegate.Username
+		}
+	case TxActionIpfs:
+		if asset.Ipfs != nil {
+			ext["ipfs"] = asset.Ipfs.Dag
+		}
+	case TxActionSecondSignature:
+		if asset.Signature != nil {
+			ext["publicKey"] = asset.Signature.PublicKey
+		}
+	case TxActionHtlcLock:
+		if asset.Lock != nil {
+			ext["status"] = "locked"
+			ext["secretHash"] = asset.Lock.SecretHash
+			ext["expirationType"] = asset.Lock.Expiration.Type
+			ext["expirationValue"] = asset.Lock.Expiration.Value
+		}
+	case TxActionHtlcClaim, TxActionHtlcRefund:
+		ext["lockTransactionId"] = htlcLockTransactionID(trans)
+		ext["status"] = "refunded"
+		if asset.Claim != nil {
+			ext["status"] = "claimed"
+			ext["unlockSecret"] = asset.Claim.UnlockSecret
+		}
+	case TxActionMultiSignature:
+		if asset.MultiSignature != nil {
+			publicKeys := asset.MultiSignature.PublicKeys
+			if len(publicKeys) == 0 {
+				publicKeys = asset.MultiSignature.Keysgroup
+			}
+			ext["min"] = asset.MultiSignature.Min
+			ext["publicKeys"] = publicKeys
+		}
+	}
+	return ext
+}
+
+//htlcLockTransactionID HTLC领取或退回交易对应的锁定交易ID
+func htlcLockTransactionID(trans *client.Transaction) string {
+	if trans.Asset == nil {
+		return ""
+	}
+	if trans.Asset.Claim != nil {
+		return trans.Asset.Claim.LockTransactionId
+	}
+	if trans.Asset.Refund != nil {
+		return trans.Asset.Refund.LockTransactionId
+	}
+	return ""
+}
+
+//htlcUnlockPayments HTLC解锁后的入账，领取入账给锁定交易的收款人，退回入账给锁定交易的发送者
+func htlcUnlockPayments(action string, lockTx *client.Transaction) []*client.MultiPaymentAsset {
+	recipient := lockTx.Recipient
+	if action == TxActionHtlcRefund {
+		recipient = lockTx.Sender
+	}
+	return []*client.MultiPaymentAsset{{Amount: lockTx.Amount, RecipientId: recipient}}
+}
+
+//getLockTransaction 查询HTLC领取或退回交易对应的锁定交易
+func (bs *ARKBlockScanner) getLockTransaction(trans *client.Transaction) (*client.Transaction, error) {
+
+	lockID := htlcLockTransactionID(trans)
+	if len(lockID) == 0 {
+		return nil, fmt.Errorf("transaction [%s] has no lock transaction id", trans.Id)
+	}
+
+	result, resp, err := bs.wm.Api.Client.Transactions.Get(bs.wm.Context, lockID)
+	err = checkNodeResponse(resp, err)
+	if err == nil && result == nil {
+		err = errEmptyNodeResponse
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can not get lock transaction [%s], unexpected error: %v", lockID, err)
+	}
+
+	if result.Data.Id != lockID || transactionAction(&result.Data) != TxActionHtlcLock {
+		return nil, fmt.Errorf("transaction [%s] is not a lock transaction", lockID)
+	}
+
+	//锁定交易决定入账的金额及收款人，开启本地校验时同样校验
+	if bs.wm.Config.VerifyBlocks {
+		if err := bs.verifyTransaction(&result.Data); err != nil {
+			return nil, fmt.Errorf("lock transaction [%s] verify failed: %v", lockID, err)
+		}
+	}
+
+	return &result.Data, nil
+}
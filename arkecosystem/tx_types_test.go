@@ -0,0 +1,271 @@
+package arkecosystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blocktree/arkecosystem-adapter/sdk/client"
+	"github.com/blocktree/openwallet/v2/openwallet"
+)
+
+func testExtractTransaction(t *testing.T, raw string) *openwallet.TxExtractData {
+	var tx client.Transaction
+	if err := json.Unmarshal([]byte(raw), &tx); err != nil {
+		t.Fatalf("decode transaction error: %v", err)
+	}
+	wm := NewWalletManager()
+	result, err := wm.Blockscanner.changeTrans(&tx, func(target openwallet.ScanTarget) (string, bool) {
+		return "account", target.Address == testWatchedAddress
+	})
+	if err != nil {
+		t.Fatalf("changeTrans error: %v", err)
+	}
+	data := result.extractData["account"]
+	if data == nil {
+		t.Fatalf("changeTrans got no extract data")
+	}
+	return data
+}
+
+func TestChangeTrans_Vote(t *testing.T) {
+	data := testExtractTransaction(t, `{
+		"id": "vote", "type": 3, "typeGroup": 1, "amount": "0", "fee": "100000000",
+		"sender": "`+testWatchedAddress+`", "recipient": "`+testWatchedAddress+`",
+		"asset": {"votes": ["+022cca9529ec97a772156c152a00aad155ee6708243e65c9d211a589cb5d43234d"]}
+	}`)
+
+	if len(data.TxOutputs) != 0 {
+		t.Errorf("vote should not have outputs, got %d", len(data.TxOutputs))
+	}
+	if len(data.TxInputs) != 1 || data.TxInputs[0].Amount != "1" {
+		t.Errorf("vote should only have fee input, got %d", len(data.TxInputs))
+	}
+	if data.Transaction.TxAction != TxActionVote ||
+		data.Transaction.GetExtParam().Get("votes.0").String() != "+022cca9529ec97a772156c152a00aad155ee6708243e65c9d211a589cb5d43234d" {
+		t.Errorf("vote ext param got %s", data.Transaction.ExtParam)
+	}
+}
+
+func TestChangeTrans_TypedExtParam(t *testing.T) {
+	tests := []struct {
+		raw   string
+		key   string
+		value string
+	}{
+		{
+			raw:   `{"id": "a", "type": 2, "typeGroup": 1, "fee": "2500000000", "sender": "` + testWatchedAddress + `", "asset": {"delegate": {"username": "genesis_1"}}}`,
+			key:   "username",
+			value: "genesis_1",
+		},
+		{
+			raw:   `{"id": "b", "type": 5, "typeGroup": 1, "fee": "500000000", "sender": "` + testWatchedAddress + `", "asset": {"ipfs": "QmR45FmbVVrixReBwJkhEKde2qwHYaQzGxu4ZoDeswuF9w"}}`,
+			key:   "ipfs",
+			value: "QmR45FmbVVrixReBwJkhEKde2qwHYaQzGxu4ZoDeswuF9w",
+		},
+		{
+			raw:   `{"id": "c", "type": 1, "typeGroup": 1, "fee": "500000000", "sender": "` + testWatchedAddress + `", "asset": {"signature": {"publicKey": "03699e966b2525f9088a6941d8d94f7869964a000efe65783d78ac82e1199fe609"}}}`,
+			key:   "publicKey",
+			value: "03699e966b2525f9088a6941d8d94f7869964a000efe65783d78ac82e1199fe609",
+		},
+		{
+			raw:   `{"id": "d", "type": 7, "typeGroup": 1, "fee": "2500000000", "sender": "` + testWatchedAddress + `"}`,
+			key:   "action",
+			value: TxActionDelegateResignation,
+		},
+	}
+
+	for _, test := range tests {
+		data := testExtractTransaction(t, test.raw)
+		if got := data.Transaction.GetExtParam().Get(test.key).String(); got != test.value {
+			t.Errorf("ext param %s got %s, want %s", test.key, got, test.value)
+		}
+		if len(data.TxOutputs) != 0 || len(data.TxInputs) != 1 {
+			t.Errorf("transaction %s should only have fee input", data.Transaction.TxID)
+		}
+	}
+}
+
+func TestChangeTrans_SelfTransfer(t *testing.T) {
+	data := testExtractTransaction(t, `{
+		"id": "self", "type": 0, "typeGroup": 1, "amount": "300000000", "fee": "10000000",
+		"sender": "`+testWatchedAddress+`", "recipient": "`+testWatchedAddress+`"
+	}`)
+
+	if len(data.TxInputs) != 2 || len(data.TxOutputs) != 1 {
+		t.Errorf("self transfer got %d inputs, %d outputs", len(data.TxInputs), len(data.TxOutputs))
+		return
+	}
+	if data.TxInputs[0].Sid == data.TxInputs[1].Sid {
+		t.Errorf("amount and fee inputs should have distinct sid")
+	}
+	if data.TxInputs[0].Amount != "3" || data.TxInputs[1].Amount != "0.1" || data.TxOutputs[0].Amount != "3" {
+		t.Errorf("self transfer amounts got %s %s %s", data.TxInputs[0].Amount, data.TxInputs[1].Amount, data.TxOutputs[0].Amount)
+	}
+	if data.Transaction.TxAction != TxActionTransfer {
+		t.Errorf("self transfer action got %s", data.Transaction.TxAction)
+	}
+}
+
+func testLockServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc(baseURLPath+"/transactions/lock-in", func(writer http.ResponseWriter, request *http.Request) {
+		fmt.Fprintf(writer, `{"data": {"id": "lock-in", "type": 8, "typeGroup": 1, "amount": "500000000",
+			"sender": "AUexKjGtgsSpVzPLs6jNMM6vJ6znEVTQWK", "recipient": "%s"}}`, testWatchedAddress)
+	})
+	mux.HandleFunc(baseURLPath+"/transactions/lock-out", func(writer http.ResponseWriter, request *http.Request) {
+		fmt.Fprintf(writer, `{"data": {"id": "lock-out", "type": 8, "typeGroup": 1, "amount": "700000000",
+			"sender": "%s", "recipient": "AUexKjGtgsSpVzPLs6jNMM6vJ6znEVTQWK"}}`, testWatchedAddress)
+	})
+	mux.HandleFunc(baseURLPath+"/transactions/transfer", func(writer http.ResponseWriter, request *http.Request) {
+		fmt.Fprint(writer, `{"data": {"id": "transfer", "type": 0, "typeGroup": 1, "amount": "700000000"}}`)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestChangeTrans_Htlc(t *testing.T) {
+	server := testLockServer()
+	defer server.Close()
+
+	wm := NewWalletManager()
+	wm.Api = NewApi(server.URL)
+	scanTargetFunc := func(target openwallet.ScanTarget) (string, bool) {
+		return "account", target.Address == testWatchedAddress
+	}
+	extract := func(raw string) (*openwallet.TxExtractData, error) {
+		var tx client.Transaction
+		if err := json.Unmarshal([]byte(raw), &tx); err != nil {
+			return nil, err
+		}
+		result, err := wm.Blockscanner.changeTrans(&tx, scanTargetFunc)
+		if err != nil {
+			return nil, err
+		}
+		return result.extractData["account"], nil
+	}
+
+	//锁定交易的收款人不入账
+	lock, err := extract(`{"id": "lock-in", "type": 8, "typeGroup": 1, "amount": "500000000", "fee": "10000000",
+		"sender": "AUexKjGtgsSpVzPLs6jNMM6vJ6znEVTQWK", "recipient": "` + testWatchedAddress + `",
+		"asset": {"lock": {"secretHash": "09b9a28393efd02fcd76a21b0f0f55ba2aad8f3640ff8cae86de033a9cfbd78c", "expiration": {"type": 2, "value": 2000}}}}`)
+	if err != nil || lock == nil {
+		t.Fatalf("extract lock got %v, %v", lock, err)
+	}
+	if len(lock.TxOutputs) != 0 || lock.Transaction.GetExtParam().Get("status").String() != "locked" ||
+		lock.Transaction.GetExtParam().Get("expirationValue").Int() != 2000 {
+		t.Errorf("lock got %d outputs, ext param %s", len(lock.TxOutputs), lock.Transaction.ExtParam)
+	}
+
+	//领取时按锁定交易入账
+	claim, err := extract(`{"id": "claim", "type": 9, "typeGroup": 1, "amount": "0", "fee": "0",
+		"sender": "` + testWatchedAddress + `",
+		"asset": {"claim": {"lockTransactionId": "lock-in", "unlockSecret": "my secret that should be 32bytes"}}}`)
+	if err != nil || claim == nil {
+		t.Fatalf("extract claim got %v, %v", claim, err)
+	}
+	if len(claim.TxOutputs) != 1 || claim.TxOutputs[0].Amount != "5" || claim.Transaction.Amount != "5" ||
+		claim.Transaction.GetExtParam().Get("lockTransactionId").String() != "lock-in" {
+		t.Errorf("claim got %d outputs, ext param %s", len(claim.TxOutputs), claim.Transaction.ExtParam)
+	}
+
+	//退回时入账给锁定交易的发送者
+	refund, err := extract(`{"id": "refund", "type": 10, "typeGroup": 1, "amount": "0", "fee": "0",
+		"sender": "` + testWatchedAddress + `", "asset": {"refund": {"lockTransactionId": "lock-out"}}}`)
+	if err != nil || refund == nil {
+		t.Fatalf("extract refund got %v, %v", refund, err)
+	}
+	if len(refund.TxOutputs) != 1 || refund.TxOutputs[0].Amount != "7" || refund.TxOutputs[0].Address != testWatchedAddress {
+		t.Errorf("refund got %d outputs", len(refund.TxOutputs))
+	}
+
+	//对应的交易不是锁定交易
+	_, err = extract(`{"id": "claim2", "type": 9, "typeGroup": 1, "sender": "` + testWatchedAddress + `",
+		"asset": {"claim": {"lockTransactionId": "transfer"}}}`)
+	if err == nil {
+		t.Errorf("claim of a non-lock transaction should fail")
+	}
+
+	//开启本地校验时，未签名的锁定交易不入账
+	wm.Config.VerifyBlocks = true
+	_, err = extract(`{"id": "claim", "type": 9, "typeGroup": 1, "amount": "0", "fee": "0",
+		"sender": "` + testWatchedAddress + `",
+		"asset": {"claim": {"lockTransactionId": "lock-in", "unlockSecret": "my secret that should be 32bytes"}}}`)
+	if err == nil || !strings.Contains(err.Error(), "verify failed") {
+		t.Errorf("claim of an unverified lock transaction got error: %v", err)
+	}
+}
+
+func TestChangeTrans_Memo(t *testing.T) {
+	wm := NewWalletManager()
+	wm.Blockscanner.MemoScanTargetFunc = func(target openwallet.ScanTarget, memo string) (string, bool) {
+		return "user-" + memo, target.Address == testWatchedAddress && memo == "10086"
+	}
+	scanTargetFunc := func(target openwallet.ScanTarget) (string, bool) {
+		return "account", target.Address == testWatchedAddress
+	}
+
+	tests := []struct {
+		raw     string
+		key     string
+		memo    string
+		memoHex string
+	}{
+		{
+			raw:     `{"id": "a", "type": 0, "amount": "100000000", "recipient": "` + testWatchedAddress + `", "vendorField": "10086"}`,
+			key:     "user-10086",
+			memo:    "10086",
+			memoHex: "3130303836",
+		},
+		{
+			raw:     `{"id": "b", "type": 0, "amount": "100000000", "recipient": "` + testWatchedAddress + `", "vendorField": "10010"}`,
+			key:     "account",
+			memo:    "10010",
+			memoHex: "3130303130",
+		},
+		{
+			raw:     `{"id": "c", "type": 0, "amount": "100000000", "recipient": "` + testWatchedAddress + `", "vendorFieldHex": "ff00fe"}`,
+			key:     "account",
+			memo:    "",
+			memoHex: "ff00fe",
+		},
+	}
+
+	for _, test := range tests {
+		var tx client.Transaction
+		json.Unmarshal([]byte(test.raw), &tx)
+		result, err := wm.Blockscanner.changeTrans(&tx, scanTargetFunc)
+		if err != nil {
+			t.Errorf("changeTrans error: %v", err)
+			continue
+		}
+		data := result.extractData[test.key]
+		if data == nil || len(data.TxOutputs) != 1 {
+			t.Errorf("transaction %s should be routed to %s", tx.Id, test.key)
+			continue
+		}
+		output := data.TxOutputs[0]
+		if !output.IsMemo || output.Memo != test.memo || data.Transaction.Memo != test.memo {
+			t.Errorf("transaction %s memo got %s, want %s", tx.Id, output.Memo, test.memo)
+		}
+		if got := data.Transaction.GetExtParam().Get("vendorFieldHex").String(); got != test.memoHex {
+			t.Errorf("transaction %s memo hex got %s, want %s", tx.Id, got, test.memoHex)
+		}
+	}
+
+	//转出交易的输入同样记录备注
+	var tx client.Transaction
+	json.Unmarshal([]byte(`{"id": "d", "type": 0, "amount": "100000000", "sender": "`+testWatchedAddress+`",
+		"recipient": "AUexKjGtgsSpVzPLs6jNMM6vJ6znEVTQWK", "vendorFieldHex": "ff00fe"}`), &tx)
+	result, err := wm.Blockscanner.changeTrans(&tx, scanTargetFunc)
+	if err != nil || result.extractData["account"] == nil || len(result.extractData["account"].TxInputs) != 2 {
+		t.Fatalf("changeTrans of outgoing transaction got %v", err)
+	}
+	for _, input := range result.extractData["account"].TxInputs {
+		if !input.IsMemo || input.Memo != "ff00fe" {
+			t.Errorf("input %d memo got %s, want ff00fe", input.Index, input.Memo)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package arkecosystem
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/blocktree/openwallet/v2/openwallet"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	//未扫记录默认的最大尝试次数，超过后转入死信
+	defaultUnscanMaxAttempts = 10
+	//未扫记录默认的首次重试间隔，之后每次失败翻倍
+	defaultUnscanRetryInterval = time.Minute
+	//未扫记录默认的最大重试间隔
+	defaultUnscanMaxRetryInterval = time.Hour
+	//未扫记录重试状态的本地文件名
+	unscanRetryFileName = "unscan_retry.json"
+	//未说明原因的失败
+	unknownUnscanReason = "unknown error"
+
+	//HealthEventUnscanDeadLetter 未扫记录超过最大尝试次数，转入死信
+	HealthEventUnscanDeadLetter = "unscanDeadLetter"
+)
+
+//UnscanRetryState 未扫记录的重试状态
+type UnscanRetryState struct {
+	ID          string    `json:"id"`
+	BlockHeight uint64    `json:"blockHeight"`
+	TxID        string    `json:"txid"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"lastError"`
+	NextRetry   time.Time `json:"nextRetry"`
+	DeadLetter  bool      `json:"deadLetter"`
+}
+
+//unscanRetryStore 未扫记录的重试状态，openwallet.UnscanRecord无法扩展字段，按记录ID另行保存。
+//配置了数据目录时持久化到本地文件，否则只保存在内存中
+type unscanRetryStore struct {
+	mu     sync.Mutex
+	path   string
+	loaded bool
+	states map[string]*UnscanRetryState
+}
+
+//load 首次使用或数据目录变更时从本地文件加载，内存中已有的状态较新，与文件中的状态合并而不丢弃
+func (s *unscanRetryStore) load(path string) {
+	if s.loaded && s.path == path {
+		return
+	}
+	s.loaded = true
+	s.path = path
+	if s.states == nil {
+		s.states = make(map[string]*UnscanRetryState)
+	}
+	if len(path) == 0 {
+		return
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	states := make([]*UnscanRetryState, 0)
+	if json.Unmarshal(data, &states) != nil {
+		return
+	}
+	for _, state := range states {
+		if _, ok := s.states[state.ID]; !ok {
+			s.states[state.ID] = state
+		}
+	}
+}
+
+//save 保存到本地文件
+func (s *unscanRetryStore) save() error {
+	if len(s.path) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(s.list(false))
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(s.path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+//list 按高度排序的状态副本
+func (s *unscanRetryStore) list(deadOnly bool) []*UnscanRetryState {
+	list := make([]*UnscanRetryState, 0, len(s.states))
+	for _, state := range s.states {
+		if deadOnly && !state.DeadLetter {
+			continue
+		}
+		copied := *state
+		list = append(list, &copied)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].BlockHeight != list[j].BlockHeight {
+			return list[i].BlockHeight < list[j].BlockHeight
+		}
+		return list[i].TxID < list[j].TxID
+	})
+	return list
+}
+
+//unscanRetryPath 重试状态的本地文件，未配置数据目录时不持久化
+func (bs *ARKBlockScanner) unscanRetryPath() string {
+	if len(bs.wm.Config.DataDir) == 0 {
+		return ""
+	}
+	return filepath.Join(bs.wm.Config.dbPath, unscanRetryFileName)
+}
+
+//unscanRetryBackoff 第attempts次失败后的重试间隔，按指数退避
+func (bs *ARKBlockScanner) unscanRetryBackoff(attempts int) time.Duration {
+	interval := bs.wm.Config.UnscanRetryInterval
+	max := bs.wm.Config.UnscanMaxRetryInterval
+	for i := 1; i < attempts && interval < max; i++ {
+		interval *= 2
+	}
+	if max > 0 && interval > max {
+		interval = max
+	}
+	return interval
+}
+
+//recordUnscanFailure 保存未扫记录并累计失败次数，超过最大尝试次数后转入死信
+func (bs *ARKBlockScanner) recordUnscanFailure(height uint64, txID, reason string) {
+
+	if len(reason) == 0 {
+		reason = unknownUnscanReason
+	}
+
+	record := NewUnscanRecord(height, txID, reason, bs.wm.Symbol())
+	err := bs.SaveUnscanRecord(record)
+	if err != nil {
+		bs.wm.Log.Std.Error("block height: %d, save unscan record failed. unexpected error: %v", height, err)
+	}
+
+	bs.unscanRetries.mu.Lock()
+	bs.unscanRetries.load(bs.unscanRetryPath())
+	state := bs.unscanRetries.states[record.ID]
+	if state == nil {
+		state = &UnscanRetryState{ID: record.ID, BlockHeight: height, TxID: txID}
+		bs.unscanRetries.states[record.ID] = state
+	}
+	state.Attempts++
+	state.LastError = reason
+	state.NextRetry = time.Now().Add(bs.unscanRetryBackoff(state.Attempts))
+	dead := !state.DeadLetter && bs.wm.Config.UnscanMaxAttempts > 0 && state.Attempts >= bs.wm.Config.UnscanMaxAttempts
+	if dead {
+		state.DeadLetter = true
+	}
+	attempts := state.Attempts
+	err = bs.unscanRetries.save()
+	bs.unscanRetries.mu.Unlock()
+
+	if err != nil {
+		bs.wm.Log.Std.Error("block scanner can not save unscan retry state; unexpected error: %v", err)
+	}
+
+	if dead {
+		bs.healthNotify(&ScannerHealthEvent{
+			Type:    HealthEventUnscanDeadLetter,
+			Height:  height,
+			Message: fmt.Sprintf("unscan record [%d:%s] failed %d times and was moved to dead letter: %s", height, txID, attempts, reason),
+		})
+	}
+}
+
+//unscanRetryState 记录的重试状态，升级前保存的记录没有状态，视为失败一次且立即重试
+func (bs *ARKBlockScanner) unscanRetryState(record *openwallet.UnscanRecord) UnscanRetryState {
+	bs.unscanRetries.mu.Lock()
+	defer bs.unscanRetries.mu.Unlock()
+	bs.unscanRetries.load(bs.unscanRetryPath())
+	state := bs.unscanRetries.states[record.ID]
+	if state == nil {
+		state = &UnscanRetryState{
+			ID:          record.ID,
+			BlockHeight: record.BlockHeight,
+			TxID:        record.TxID,
+			Attempts:    1,
+			LastError:   record.Reason,
+		}
+		bs.unscanRetries.states[record.ID] = state
+	}
+	return *state
+}
+
+//removeUnscanRetryStates 删除重试状态，keep不为nil时删除不在keep中的状态
+func (bs *ARKBlockScanner) removeUnscanRetryStates(ids []string, keep map[string]bool) {
+	bs.unscanRetries.mu.Lock()
+	defer bs.unscanRetries.mu.Unlock()
+	bs.unscanRetries.load(bs.unscanRetryPath())
+	changed := false
+	for _, id := range ids {
+		if _, ok := bs.unscanRetries.states[id]; ok {
+			delete(bs.unscanRetries.states, id)
+			changed = true
+		}
+	}
+	if keep != nil {
+		for id := range bs.unscanRetries.states {
+			if !keep[id] {
+				delete(bs.unscanRetries.states, id)
+				changed = true
+			}
+		}
+	}
+	if changed {
+		if err := bs.unscanRetries.save(); err != nil {
+			bs.wm.Log.Std.Error("block scanner can not save unscan retry state; unexpected error: %v", err)
+		}
+	}
+}
+
+//renameUnscanRetryState 未扫记录更换ID后迁移其重试状态
+func (bs *ARKBlockScanner) renameUnscanRetryState(oldID, newID string) {
+	bs.unscanRetries.mu.Lock()
+	defer bs.unscanRetries.mu.Unlock()
+	bs.unscanRetries.load(bs.unscanRetryPath())
+	state := bs.unscanRetries.states[oldID]
+	if state == nil {
+		return
+	}
+	delete(bs.unscanRetries.states, oldID)
+	if _, ok := bs.unscanRetries.states[newID]; !ok {
+		state.ID = newID
+		bs.unscanRetries.states[newID] = state
+	}
+	if err := bs.unscanRetries.save(); err != nil {
+		bs.wm.Log.Std.Error("block scanner can not save unscan retry state; unexpected error: %v", err)
+	}
+}
+
+//migrateUnscanRecords 升级前保存的记录ID未包含币种，按新ID重新保存并迁移重试状态
+func (bs *ARKBlockScanner) migrateUnscanRecords(list []*openwallet.UnscanRecord) []*openwallet.UnscanRecord {
+	migrated := make([]*openwallet.UnscanRecord, 0, len(list))
+	for _, l := range list {
+		record := NewUnscanRecord(l.BlockHeight, l.TxID, l.Reason, bs.wm.Symbol())
+		if record.ID == l.ID {
+			migrated = append(migrated, l)
+			continue
+		}
+		err := bs.SaveUnscanRecord(record)
+		if err != nil {
+			bs.wm.Log.Std.Error("block height: %d, migrate unscan record failed. unexpected error: %v", l.BlockHeight, err)
+			migrated = append(migrated, l)
+			continue
+		}
+		bs.DeleteUnscanRecordByID(l.ID)
+		bs.renameUnscanRetryState(l.ID, record.ID)
+		migrated = append(migrated, record)
+	}
+	return migrated
+}
+
+//UnscanRecordStates 全部未扫记录的重试状态
+func (bs *ARKBlockScanner) UnscanRecordStates() []*UnscanRetryState {
+	bs.unscanRetries.mu.Lock()
+	defer bs.unscanRetries.mu.Unlock()
+	bs.unscanRetries.load(bs.unscanRetryPath())
+	return bs.unscanRetries.list(false)
+}
+
+//DeadLetterRecords 超过最大尝试次数不再自动重试的未扫记录
+func (bs *ARKBlockScanner) DeadLetterRecords() []*UnscanRetryState {
+	bs.unscanRetries.mu.Lock()
+	defer bs.unscanRetries.mu.Unlock()
+	bs.unscanRetries.load(bs.unscanRetryPath())
+	return bs.unscanRetries.list(true)
+}
+
+//RequeueUnscanRecord 重置未扫记录的尝试次数，下次扫描任务时立即重试
+func (bs *ARKBlockScanner) RequeueUnscanRecord(id string) error {
+	bs.unscanRetries.mu.Lock()
+	defer bs.unscanRetries.mu.Unlock()
+	bs.unscanRetries.load(bs.unscanRetryPath())
+	state := bs.unscanRetries.states[id]
+	if state == nil {
+		return fmt.Errorf("unscan record [%s] is not found", id)
+	}
+	state.Attempts = 0
+	state.DeadLetter = false
+	state.NextRetry = time.Time{}
+	return bs.unscanRetries.save()
+}
+
+//retryUnscanRecord 重试单条未扫记录，失败时已累计失败次数
+func (bs *ARKBlockScanner) retryUnscanRecord(record *openwallet.UnscanRecord) error {
+
+	if len(record.TxID) > 0 {
+		return bs.retryUnscanTransaction(record)
+	}
+
+	block, err := bs.getBlockByHeight(record.BlockHeight)
+	if err != nil {
+		bs.recordUnscanFailure(record.BlockHeight, "", err.Error())
+		return err
+	}
+
+	return bs.BatchExtractTransaction(block)
+}
+
+//retryUnscanTransaction 重新提取并通知单笔交易
+func (bs *ARKBlockScanner) retryUnscanTransaction(record *openwallet.UnscanRecord) error {
+
+	result, resp, err := bs.wm.Api.Client.Transactions.Get(context.Background(), record.TxID)
+	err = checkNodeResponse(resp, err)
+	if err == nil && result == nil {
+		err = errEmptyNodeResponse
+	}
+	if err != nil {
+		bs.recordUnscanFailure(record.BlockHeight, record.TxID, err.Error())
+		return err
+	}
+
+	trans := &result.Data
+	trans.BlockHeight = int64(record.BlockHeight)
+	extract, err := bs.changeTrans(trans, bs.ScanTargetFunc)
+	if err != nil {
+		bs.recordUnscanFailure(record.BlockHeight, record.TxID, err.Error())
+		return err
+	}
+
+	return bs.newExtractDataNotify(record.BlockHeight, []*ExtractTxResult{&extract})
+}
+
+//ExtractNotifyError 观测者处理交易失败，已按交易记录未扫记录
+type ExtractNotifyError struct {
+	Height uint64
+	TxIDs  []string
+}
+
+func (e *ExtractNotifyError) Error() string {
+	return fmt.Sprintf("block height: %d, notify transactions %v failed", e.Height, e.TxIDs)
+}
@@ -0,0 +1,240 @@
+package arkecosystem
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blocktree/openwallet/v2/openwallet"
+)
+
+//testFailingObserver 通知指定交易时返回错误
+type testFailingObserver struct {
+	testObserver
+	failTxID string
+	failing  bool
+}
+
+func (o *testFailingObserver) BlockExtractDataNotify(sourceKey string, data *openwallet.TxExtractData) error {
+	o.mu.Lock()
+	failing := o.failing && data.Transaction.TxID == o.failTxID
+	o.mu.Unlock()
+	if failing {
+		return fmt.Errorf("observer is unavailable")
+	}
+	return o.testObserver.BlockExtractDataNotify(sourceKey, data)
+}
+
+func (o *testFailingObserver) setFailing(failing bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.failing = failing
+}
+
+func TestARKBlockScanner_UnscanRetryBackoff(t *testing.T) {
+	wm := NewWalletManager()
+	bs := wm.Blockscanner
+	tests := []struct {
+		attempts int
+		backoff  time.Duration
+	}{
+		{attempts: 1, backoff: time.Minute},
+		{attempts: 2, backoff: 2 * time.Minute},
+		{attempts: 3, backoff: 4 * time.Minute},
+		{attempts: 7, backoff: time.Hour},
+		{attempts: 100, backoff: time.Hour},
+	}
+	for _, test := range tests {
+		if got := bs.unscanRetryBackoff(test.attempts); got != test.backoff {
+			t.Errorf("backoff of attempt %d got %v, want %v", test.attempts, got, test.backoff)
+		}
+	}
+}
+
+func TestARKBlockScanner_RescanFailedTransaction(t *testing.T) {
+	chain := newTestChain(5, 2)
+	bs, dai, _, closeServer := newTestScanner(chain)
+	defer closeServer()
+
+	observer := &testFailingObserver{failTxID: "block-3-tx-1", failing: true}
+	bs.AddObserver(observer)
+
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 1, Hash: "block-1"})
+	bs.ScanBlockTask()
+
+	//通知失败的交易单独记录，不影响区块的扫描
+	if dai.head.Height != 5 {
+		t.Fatalf("scanned head got %d, want 5", dai.head.Height)
+	}
+	states := bs.UnscanRecordStates()
+	if len(dai.unscan) != 1 || len(states) != 1 {
+		t.Fatalf("got %d unscan records and %d states, want 1", len(dai.unscan), len(states))
+	}
+	state := states[0]
+	if state.TxID != "block-3-tx-1" || state.BlockHeight != 3 || state.Attempts != 1 ||
+		!strings.Contains(state.LastError, "observer is unavailable") || !state.NextRetry.After(time.Now()) {
+		t.Errorf("unscan state got %+v", state)
+	}
+	if record := dai.unscan[state.ID]; record == nil || record.TxID != "block-3-tx-1" {
+		t.Errorf("unscan record got %+v, want txid block-3-tx-1", record)
+	}
+
+	//未到重试时间不重试
+	observer.setFailing(false)
+	bs.RescanFailedRecord()
+	if len(dai.unscan) != 1 || bs.UnscanRecordStates()[0].Attempts != 1 {
+		t.Fatalf("record should not be retried before its next retry time")
+	}
+
+	if err := bs.RequeueUnscanRecord(state.ID); err != nil {
+		t.Fatalf("requeue error: %v", err)
+	}
+	bs.RescanFailedRecord()
+	if len(dai.unscan) != 0 || len(bs.UnscanRecordStates()) != 0 {
+		t.Errorf("retried record should be deleted, got %d records", len(dai.unscan))
+	}
+
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	retried := 0
+	for _, txID := range observer.txIDs {
+		if txID == "block-3-tx-1" {
+			retried++
+		}
+	}
+	if retried != 1 {
+		t.Errorf("failed transaction notified %d times, want 1", retried)
+	}
+}
+
+func TestARKBlockScanner_UnscanDeadLetter(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "unscan")
+	if err != nil {
+		t.Fatalf("create data dir error: %v", err)
+	}
+	defer os.RemoveAll(dataDir)
+
+	bs, dai, _, closeServer := newTestScanner(newTestChain(10, 1))
+	defer closeServer()
+	bs.wm.Config.DataDir = dataDir
+	bs.wm.Config.dbPath = filepath.Join(dataDir, "db")
+	bs.wm.Config.UnscanMaxAttempts = 3
+	bs.wm.Config.UnscanRetryInterval = 0
+
+	observer := &testHealthObserver{}
+	bs.AddObserver(observer)
+
+	//区块不存在，每次重试都失败
+	bs.recordUnscanFailure(50, "", "")
+	if state := bs.UnscanRecordStates()[0]; state.LastError != unknownUnscanReason {
+		t.Errorf("empty reason got %q", state.LastError)
+	}
+
+	bs.RescanFailedRecord()
+	bs.RescanFailedRecord()
+	bs.RescanFailedRecord()
+
+	dead := bs.DeadLetterRecords()
+	if len(dead) != 1 || dead[0].Attempts != 3 || dead[0].BlockHeight != 50 || dead[0].LastError == unknownUnscanReason {
+		t.Fatalf("dead letter records got %+v", dead)
+	}
+	if len(dai.unscan) != 1 {
+		t.Errorf("dead letter record should be kept, got %d records", len(dai.unscan))
+	}
+	events := observer.healthEvents()
+	if len(events) != 1 || events[0].Type != HealthEventUnscanDeadLetter || events[0].Height != 50 {
+		t.Errorf("health events got %+v", events)
+	}
+
+	//重试状态保存在数据目录，重启后恢复
+	bs.unscanRetries = &unscanRetryStore{}
+	if dead := bs.DeadLetterRecords(); len(dead) != 1 || dead[0].Attempts != 3 {
+		t.Fatalf("reloaded dead letter records got %+v", dead)
+	}
+
+	if err := bs.RequeueUnscanRecord("unknown"); err == nil {
+		t.Errorf("requeue unknown record should fail")
+	}
+	if err := bs.RequeueUnscanRecord(dead[0].ID); err != nil {
+		t.Fatalf("requeue error: %v", err)
+	}
+	if len(bs.DeadLetterRecords()) != 0 {
+		t.Errorf("requeued record should leave dead letter")
+	}
+	bs.RescanFailedRecord()
+	if state := bs.UnscanRecordStates()[0]; state.Attempts != 1 || state.DeadLetter {
+		t.Errorf("requeued record state got %+v", state)
+	}
+}
+
+func TestARKBlockScanner_UnscanRecordSymbol(t *testing.T) {
+	bs, dai, _, closeServer := newTestScanner(newTestChain(10, 1))
+	defer closeServer()
+	bs.wm.Config.UnscanRetryInterval = time.Hour
+
+	//同一数据库中不同链的记录互不覆盖
+	ark := NewUnscanRecord(50, "tx", "", bs.wm.Symbol())
+	bind := NewUnscanRecord(50, "tx", "", "BIND")
+	if ark.Symbol != bs.wm.Symbol() || ark.ID == bind.ID {
+		t.Errorf("unscan record got symbol %s, id %s, other chain id %s", ark.Symbol, ark.ID, bind.ID)
+	}
+
+	//升级前保存的记录按新ID重新保存，重试状态随之迁移
+	legacy := &openwallet.UnscanRecord{ID: "legacy", BlockHeight: 50, Reason: "timeout"}
+	dai.SaveUnscanRecord(legacy)
+	bs.unscanRetries.load(bs.unscanRetryPath())
+	bs.unscanRetries.states[legacy.ID] = &UnscanRetryState{ID: legacy.ID, BlockHeight: 50, Attempts: 2, NextRetry: time.Now().Add(time.Hour)}
+
+	bs.RescanFailedRecord()
+	migrated := NewUnscanRecord(50, "", "", bs.wm.Symbol())
+	if record := dai.unscan[migrated.ID]; len(dai.unscan) != 1 || record == nil || record.Symbol != bs.wm.Symbol() {
+		t.Fatalf("migrated unscan records got %+v", dai.unscan)
+	}
+	states := bs.UnscanRecordStates()
+	if len(states) != 1 || states[0].ID != migrated.ID || states[0].Attempts != 2 {
+		t.Errorf("migrated unscan states got %+v", states)
+	}
+}
+
+func TestARKBlockScanner_UnscanRetryDataDirChanged(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "unscan")
+	if err != nil {
+		t.Fatalf("create data dir error: %v", err)
+	}
+	defer os.RemoveAll(dataDir)
+
+	bs, _, _, closeServer := newTestScanner(newTestChain(10, 1))
+	defer closeServer()
+	bs.wm.Config.UnscanRetryInterval = time.Hour
+
+	//数据目录中已有其他记录的状态
+	bs.wm.Config.DataDir = dataDir
+	bs.wm.Config.dbPath = filepath.Join(dataDir, "db")
+	bs.recordUnscanFailure(40, "", "timeout")
+
+	//未配置数据目录时的状态只保存在内存中
+	bs.wm.Config.DataDir = ""
+	bs.unscanRetries = &unscanRetryStore{}
+	bs.recordUnscanFailure(50, "", "timeout")
+	bs.recordUnscanFailure(50, "", "timeout")
+	nextRetry := bs.UnscanRecordStates()[0].NextRetry
+
+	//配置数据目录后合并文件中的状态，内存中的重试次数及退避时间不丢失
+	bs.wm.Config.DataDir = dataDir
+	states := bs.UnscanRecordStates()
+	if len(states) != 2 || states[0].BlockHeight != 40 || states[1].Attempts != 2 || !states[1].NextRetry.Equal(nextRetry) {
+		t.Fatalf("merged unscan states got %+v", states)
+	}
+
+	//合并后的状态在下次保存时写入文件
+	bs.recordUnscanFailure(50, "", "timeout")
+	bs.unscanRetries = &unscanRetryStore{}
+	states = bs.UnscanRecordStates()
+	if len(states) != 2 || states[1].Attempts != 3 {
+		t.Errorf("reloaded unscan states got %+v", states)
+	}
+}
@@ -0,0 +1,478 @@
+package arkecosystem
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"github.com/blocktree/arkecosystem-adapter/sdk/client"
+	"github.com/blocktree/arkecosystem-adapter/sdk/crypto"
+	b58 "github.com/btcsuite/btcutil/base58"
+	"strconv"
+)
+
+const (
+	//可在本地序列化校验的交易版本（AIP-11）
+	verifiableTxVersion = 2
+	//完整sha256区块ID的hex长度，旧版本区块ID为8字节的十进制数
+	fullBlockIdLen = 64
+)
+
+//BlockVerifyError 本地校验区块或交易失败，节点数据可能被篡改，扫描器将停止扫描
+type BlockVerifyError struct {
+	Height  uint64
+	BlockID string
+	TxID    string
+	Reason  string
+}
+
+func (e *BlockVerifyError) Error() string {
+	if len(e.TxID) > 0 {
+		return fmt.Sprintf("block [%d:%s] transaction [%s] verify failed: %s", e.Height, e.BlockID, e.TxID, e.Reason)
+	}
+	return fmt.Sprintf("block [%d:%s] verify failed: %s", e.Height, e.BlockID, e.Reason)
+}
+
+//HaltError 最近一次因本地校验失败而停止扫描的错误，未发生时返回nil
+func (bs *ARKBlockScanner) HaltError() error {
+	bs.haltMu.RLock()
+	defer bs.haltMu.RUnlock()
+	return bs.haltErr
+}
+
+//haltScanning 本地校验失败，停止扫描且不推进扫描高度，需人工检查节点后重新启动扫描
+func (bs *ARKBlockScanner) haltScanning(err error) {
+	bs.haltMu.Lock()
+	bs.haltErr = err
+	bs.haltMu.Unlock()
+
+	bs.Scanning = false
+	bs.wm.Log.Std.Error("!!! block scanner halted, the api node may be compromised: %v", err)
+}
+
+//verifyBlock 校验区块头的生成者签名及区块ID，交易数量、payload hash、手续费总额，以及每笔交易的ID和签名
+func (bs *ARKBlockScanner) verifyBlock(block *client.Block, transactionList []client.Transaction) error {
+
+	verifyErr := func(txID, format string, args ...interface{}) error {
+		return &BlockVerifyError{
+			Height:  uint64(block.Height),
+			BlockID: block.Id,
+			TxID:    txID,
+			Reason:  fmt.Sprintf(format, args...),
+		}
+	}
+
+	if err := verifyBlockHeader(block); err != nil {
+		return verifyErr("", "%v", err)
+	}
+
+	if uint32(len(transactionList)) != block.Transactions {
+		return verifyErr("", "transactions count %d mismatch the block header %d", len(transactionList), block.Transactions)
+	}
+
+	if err := verifyPayloadHash(block, transactionList); err != nil {
+		return verifyErr("", "%v", err)
+	}
+
+	totalFee := uint64(0)
+	for i := range transactionList {
+		trans := &transactionList[i]
+		if trans.BlockId != block.Id {
+			return verifyErr(trans.Id, "transaction belongs to block [%s]", trans.BlockId)
+		}
+		if err := bs.verifyTransaction(trans); err != nil {
+			return verifyErr(trans.Id, "%v", err)
+		}
+		totalFee += trans.Fee
+	}
+
+	if totalFee != block.Forged.Fee {
+		return verifyErr("", "total fee %d mismatch the block header %d", totalFee, block.Forged.Fee)
+	}
+
+	return nil
+}
+
+//serializeBlockHeader 按节点的区块序列化格式拼接区块头，不包含签名
+func serializeBlockHeader(block *client.Block) ([]byte, error) {
+
+	ser := new(bytes.Buffer)
+	binary.Write(ser, binary.LittleEndian, uint32(block.Version))
+	binary.Write(ser, binary.LittleEndian, uint32(block.Timestamp.Epoch))
+	binary.Write(ser, binary.LittleEndian, uint32(block.Height))
+
+	switch {
+	case len(block.Previous) == 0:
+		//创世区块没有上一区块
+		ser.Write(make([]byte, 8))
+	case len(block.Previous) == fullBlockIdLen:
+		previous, err := hex.DecodeString(block.Previous)
+		if err != nil {
+			return nil, fmt.Errorf("invalid previous block id: %s", block.Previous)
+		}
+		ser.Write(previous)
+	default:
+		previous, err := strconv.ParseUint(block.Previous, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid previous block id: %s", block.Previous)
+		}
+		binary.Write(ser, binary.BigEndian, previous)
+	}
+
+	binary.Write(ser, binary.LittleEndian, block.Transactions)
+	binary.Write(ser, binary.LittleEndian, block.Forged.Amount)
+	binary.Write(ser, binary.LittleEndian, block.Forged.Fee)
+	binary.Write(ser, binary.LittleEndian, block.Forged.Reward)
+	binary.Write(ser, binary.LittleEndian, block.Payload.Length)
+
+	payloadHash, err := hex.DecodeString(block.Payload.Hash)
+	if err != nil || len(payloadHash) != sha256.Size {
+		return nil, fmt.Errorf("invalid payload hash: %s", block.Payload.Hash)
+	}
+	ser.Write(payloadHash)
+
+	generator, err := hex.DecodeString(block.Generator.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid generator public key: %s", block.Generator.PublicKey)
+	}
+	ser.Write(generator)
+
+	return ser.Bytes(), nil
+}
+
+//verifyBlockHeader 校验区块ID及生成者签名
+func verifyBlockHeader(block *client.Block) error {
+
+	header, err := serializeBlockHeader(block)
+	if err != nil {
+		return err
+	}
+
+	signature, err := hex.DecodeString(block.Signature)
+	if err != nil || len(signature) == 0 {
+		return fmt.Errorf("invalid block signature: %s", block.Signature)
+	}
+
+	idHash := sha256.Sum256(append(header, signature...))
+	blockID := hex.EncodeToString(idHash[:])
+	if len(block.Id) != fullBlockIdLen {
+		blockID = strconv.FormatUint(binary.LittleEndian.Uint64(idHash[:8]), 10)
+	}
+	if blockID != block.Id {
+		return fmt.Errorf("block id mismatch the header, computed %s", blockID)
+	}
+
+	generatorKey, _ := hex.DecodeString(block.Generator.PublicKey)
+	generator, err := crypto.PublicKeyFromBytes(generatorKey)
+	if err != nil {
+		return fmt.Errorf("invalid generator public key: %v", err)
+	}
+
+	hash := sha256.Sum256(header)
+	verified, err := generator.Verify(signature, hash[:])
+	if err != nil || !verified {
+		return fmt.Errorf("generator signature verify failed: %v", err)
+	}
+
+	return nil
+}
+
+//verifyPayloadHash 校验payload hash，即区块内全部交易ID按顺序拼接后的sha256。
+//节点接口按序号倒序返回区块交易，因此顺序及倒序均可接受
+func verifyPayloadHash(block *client.Block, transactionList []client.Transaction) error {
+
+	ids := make([][]byte, 0, len(transactionList))
+	for _, trans := range transactionList {
+		id, err := hex.DecodeString(trans.Id)
+		if err != nil || len(id) != sha256.Size {
+			return fmt.Errorf("invalid transaction id: %s", trans.Id)
+		}
+		ids = append(ids, id)
+	}
+
+	payloadHash := func(ids [][]byte) string {
+		hash := sha256.Sum256(bytes.Join(ids, nil))
+		return hex.EncodeToString(hash[:])
+	}
+
+	if payloadHash(ids) == block.Payload.Hash {
+		return nil
+	}
+
+	reversed := make([][]byte, len(ids))
+	for i, id := range ids {
+		reversed[len(ids)-1-i] = id
+	}
+	if payloadHash(reversed) == block.Payload.Hash {
+		return nil
+	}
+
+	return fmt.Errorf("payload hash mismatch the transactions")
+}
+
+//verifyTransaction 以本地序列化结果重新计算交易ID，并校验发送者地址、发送者签名、二级签名及多重签名
+func (bs *ARKBlockScanner) verifyTransaction(trans *client.Transaction) error {
+
+	transaction, err := bs.wm.toCryptoTransaction(trans)
+	if err != nil {
+		return err
+	}
+
+	//入账按节点返回的发送者地址记录，须与发送者公钥在当前网络下的地址一致
+	publicKey, err := bs.wm.Config.Crypto.PublicKeyFromHex(trans.SenderPublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid sender public key: %v", err)
+	}
+	if sender := publicKey.ToAddress(); sender != trans.Sender {
+		return fmt.Errorf("sender %s mismatch the address of sender public key %s", trans.Sender, sender)
+	}
+
+	txID := bs.wm.Config.Crypto.GetId(transaction)
+	if txID != trans.Id {
+		return fmt.Errorf("transaction id mismatch the serialized transaction, computed %s", txID)
+	}
+
+	if len(transaction.Signature) == 0 && len(transaction.Signatures) == 0 {
+		return fmt.Errorf("transaction is not signed")
+	}
+
+	if len(transaction.Signature) > 0 {
+		verified, err := transaction.Verify()
+		if err != nil || !verified {
+			return fmt.Errorf("sender signature verify failed: %v", err)
+		}
+	}
+
+	//二级签名及多重签名的公钥需查询发送者钱包
+	var wallet *client.Wallet
+	if len(transaction.SecondSignature) > 0 ||
+		(len(transaction.Signatures) > 0 && transaction.Type != crypto.TRANSACTION_TYPES.MultiSignatureRegistration) {
+		result, _, err := bs.wm.Api.Client.Wallets.Get(bs.wm.Context, trans.SenderPublicKey)
+		if err == nil && result == nil {
+			err = errEmptyNodeResponse
+		}
+		if err != nil {
+			return fmt.Errorf("can not get sender wallet, unexpected error: %v", err)
+		}
+		wallet = &result.Data
+	}
+
+	if len(transaction.SecondSignature) > 0 {
+		if wallet.Attributes == nil || len(wallet.Attributes.SecondPublicKey) == 0 {
+			return fmt.Errorf("sender has no second public key")
+		}
+		secondPublicKeyBytes, _ := hex.DecodeString(wallet.Attributes.SecondPublicKey)
+		secondPublicKey, err := crypto.PublicKeyFromBytes(secondPublicKeyBytes)
+		if err != nil {
+			return fmt.Errorf("invalid second public key: %v", err)
+		}
+		verified, err := transaction.SecondVerify(secondPublicKey)
+		if err != nil || !verified {
+			return fmt.Errorf("second signature verify failed: %v", err)
+		}
+	}
+
+	if len(transaction.Signatures) > 0 {
+		multiSignature := transaction.Asset.MultiSignature
+		if transaction.Type != crypto.TRANSACTION_TYPES.MultiSignatureRegistration {
+			multiSignature = nil
+			if wallet.Attributes != nil && wallet.Attributes.MultiSignature != nil {
+				multiSignature = &crypto.MultiSignatureRegistrationAsset{
+					Min:        wallet.Attributes.MultiSignature.Min,
+					PublicKeys: wallet.Attributes.MultiSignature.PublicKeys,
+				}
+			}
+		}
+		if multiSignature == nil || !isHexList(multiSignature.PublicKeys) {
+			return fmt.Errorf("sender has no multi signature public keys")
+		}
+		verified, err := transaction.VerifyMultiSignature(multiSignature)
+		if err != nil || !verified {
+			return fmt.Errorf("multi signature verify failed: %v", err)
+		}
+	}
+
+	return nil
+}
+
+//toCryptoTransaction 将节点返回的交易转换为sdk/crypto交易用于序列化。
+//sdk/crypto序列化遇到非法数据会直接退出进程，因此转换时先检查全部字段
+func (wm *WalletManager) toCryptoTransaction(trans *client.Transaction) (*crypto.Transaction, error) {
+
+	if trans.Version != verifiableTxVersion {
+		return nil, fmt.Errorf("transaction version %d can not be verified locally", trans.Version)
+	}
+
+	if uint32(trans.TypeGroup) != crypto.TRANSACTION_TYPE_GROUPS.Core {
+		return nil, fmt.Errorf("transaction type group %d can not be verified locally", trans.TypeGroup)
+	}
+
+	action := transactionAction(trans)
+	if action == TxActionUnknown {
+		return nil, fmt.Errorf("transaction type %d can not be verified locally", trans.Type)
+	}
+
+	vendorField := trans.VendorField
+	if len(trans.VendorFieldHex) > 0 {
+		raw, err := hex.DecodeString(trans.VendorFieldHex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vendorField hex: %s", trans.VendorFieldHex)
+		}
+		vendorField = string(raw)
+	}
+	if len(vendorField) > 0xFF {
+		return nil, fmt.Errorf("vendorField is too long")
+	}
+
+	if !isHexList(append([]string{trans.SenderPublicKey, trans.Signature, trans.SignSignature}, trans.Signatures...)) {
+		return nil, fmt.Errorf("invalid public key or signatures")
+	}
+	for _, signature := range trans.Signatures {
+		if len(signature) < 2 {
+			return nil, fmt.Errorf("invalid multi signature: %s", signature)
+		}
+	}
+
+	transaction := &crypto.Transaction{
+		Amount:          crypto.FlexToshi(trans.Amount),
+		Expiration:      trans.Expiration,
+		Fee:             crypto.FlexToshi(trans.Fee),
+		Id:              trans.Id,
+		Network:         wm.Config.Crypto.GetNetwork().Version,
+		Nonce:           trans.Nonce,
+		RecipientId:     trans.Recipient,
+		SecondSignature: trans.SignSignature,
+		SenderPublicKey: trans.SenderPublicKey,
+		Signature:       trans.Signature,
+		Signatures:      trans.Signatures,
+		Type:            uint16(trans.Type),
+		TypeGroup:       uint32(trans.TypeGroup),
+		VendorField:     vendorField,
+		Version:         trans.Version,
+	}
+
+	asset, err := toCryptoTransactionAsset(trans, action)
+	if err != nil {
+		return nil, err
+	}
+	transaction.Asset = asset
+
+	if action == TxActionTransfer || action == TxActionHtlcLock {
+		if !isAddress(trans.Recipient) {
+			return nil, fmt.Errorf("invalid recipient: %s", trans.Recipient)
+		}
+	}
+
+	return transaction, nil
+}
+
+//toCryptoTransactionAsset 转换交易类型对应的asset，缺失序列化所需的字段时返回错误
+func toCryptoTransactionAsset(trans *client.Transaction, action string) (*crypto.TransactionAsset, error) {
+
+	asset := &crypto.TransactionAsset{}
+	if action == TxActionTransfer || action == TxActionDelegateResignation {
+		return asset, nil
+	}
+
+	src := trans.Asset
+	if src == nil {
+		return nil, fmt.Errorf("transaction asset is missing")
+	}
+
+	switch action {
+	case TxActionSecondSignature:
+		if src.Signature == nil || !isHexList([]string{src.Signature.PublicKey}) {
+			return nil, fmt.Errorf("invalid second signature asset")
+		}
+		asset.Signature = &crypto.SecondSignatureRegistrationAsset{PublicKey: src.Signature.PublicKey}
+	case TxActionDelegateRegistration:
+		if src.Delegate == nil || len(src.Delegate.Username) > 0xFF {
+			return nil, fmt.Errorf("invalid delegate asset")
+		}
+		asset.Delegate = &crypto.DelegateAsset{Username: src.Delegate.Username}
+	case TxActionVote:
+		if len(src.Votes) > 0xFF {
+			return nil, fmt.Errorf("too many votes")
+		}
+		for _, vote := range src.Votes {
+			if len(vote) < 2 || (vote[0] != '+' && vote[0] != '-') || !isHexList([]string{vote[1:]}) {
+				return nil, fmt.Errorf("invalid vote: %s", vote)
+			}
+		}
+		asset.Votes = src.Votes
+	case TxActionMultiSignature:
+		if src.MultiSignature == nil || len(src.MultiSignature.PublicKeys) > 0xFF || !isHexList(src.MultiSignature.PublicKeys) {
+			return nil, fmt.Errorf("invalid multi signature asset")
+		}
+		asset.MultiSignature = &crypto.MultiSignatureRegistrationAsset{
+			Min:        src.MultiSignature.Min,
+			PublicKeys: src.MultiSignature.PublicKeys,
+		}
+	case TxActionIpfs:
+		if src.Ipfs == nil {
+			return nil, fmt.Errorf("invalid ipfs asset")
+		}
+		asset.Ipfs = src.Ipfs.Dag
+	case TxActionMultiPayment:
+		if len(src.Payments) > 0xFFFF {
+			return nil, fmt.Errorf("too many payments")
+		}
+		for _, payment := range src.Payments {
+			if payment == nil || !isAddress(payment.RecipientId) {
+				return nil, fmt.Errorf("invalid payment asset")
+			}
+			asset.Payments = append(asset.Payments, &crypto.MultiPaymentAsset{
+				Amount:      crypto.FlexToshi(payment.Amount),
+				RecipientId: payment.RecipientId,
+			})
+		}
+	case TxActionHtlcLock:
+		if src.Lock == nil || !isHexList([]string{src.Lock.SecretHash}) {
+			return nil, fmt.Errorf("invalid lock asset")
+		}
+		asset.Lock = &crypto.HtlcLockAsset{
+			SecretHash: src.Lock.SecretHash,
+			Expiration: &crypto.HtlcLockExpirationAsset{
+				Type:  src.Lock.Expiration.Type,
+				Value: src.Lock.Expiration.Value,
+			},
+		}
+	case TxActionHtlcClaim:
+		if src.Claim == nil || !isHexList([]string{src.Claim.LockTransactionId}) {
+			return nil, fmt.Errorf("invalid claim asset")
+		}
+		//早期节点的unlockSecret为32字节明文，序列化时按原始字节写入
+		unlockSecret := src.Claim.UnlockSecret
+		if !isHexList([]string{unlockSecret}) {
+			unlockSecret = hex.EncodeToString([]byte(unlockSecret))
+		}
+		asset.Claim = &crypto.HtlcClaimAsset{
+			LockTransactionId: src.Claim.LockTransactionId,
+			UnlockSecret:      unlockSecret,
+		}
+	case TxActionHtlcRefund:
+		if src.Refund == nil || !isHexList([]string{src.Refund.LockTransactionId}) {
+			return nil, fmt.Errorf("invalid refund asset")
+		}
+		asset.Refund = &crypto.HtlcRefundAsset{LockTransactionId: src.Refund.LockTransactionId}
+	}
+
+	return asset, nil
+}
+
+//isHexList 全部字符串均为合法hex
+func isHexList(list []string) bool {
+	for _, s := range list {
+		if _, err := hex.DecodeString(s); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+//isAddress 是否为合法的base58check地址
+func isAddress(address string) bool {
+	_, _, err := b58.CheckDecode(address)
+	return err == nil
+}
@@ -0,0 +1,241 @@
+package arkecosystem
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/blocktree/arkecosystem-adapter/sdk/client"
+	"github.com/blocktree/arkecosystem-adapter/sdk/crypto"
+	"github.com/blocktree/openwallet/v2/openwallet"
+)
+
+const (
+	testSenderPassphrase    = "this is a top secret passphrase"
+	testGeneratorPassphrase = "this is the generator passphrase"
+)
+
+//newSignedTestChain 生成带有真实签名交易及区块签名的模拟链，区块交易按节点接口的倒序返回
+func newSignedTestChain(t *testing.T, config *crypto.Config, height int, txsPerBlock int) *testChain {
+	sender, _ := config.PrivateKeyFromPassphrase(testSenderPassphrase)
+	generator, _ := config.PrivateKeyFromPassphrase(testGeneratorPassphrase)
+
+	chain := &testChain{txs: make(map[string][]client.Transaction)}
+	nonce := uint64(1)
+	for h := 1; h <= height; h++ {
+		block := client.Block{
+			Height:       int64(h),
+			Transactions: uint32(txsPerBlock),
+			Timestamp:    client.Timestamp{Epoch: int32(h * 8)},
+			Forged:       client.BlockForged{Reward: 200000000},
+			Generator:    client.BlockGenerator{PublicKey: hex.EncodeToString(generator.PublicKey.Serialize())},
+		}
+		if h > 1 {
+			block.Previous = chain.blocks[h-2].Id
+		}
+
+		txs := make([]client.Transaction, 0, txsPerBlock)
+		ids := make([][]byte, 0, txsPerBlock)
+		for i := 0; i < txsPerBlock; i++ {
+			transaction := config.BuildTransfer(&crypto.Transaction{
+				Amount:      100000000,
+				Fee:         10000000,
+				Nonce:       nonce,
+				RecipientId: testWatchedAddress,
+				VendorField: "signed",
+			}, testSenderPassphrase, "")
+			nonce++
+
+			txs = append([]client.Transaction{{
+				Id:              transaction.Id,
+				Version:         transaction.Version,
+				Type:            byte(transaction.Type),
+				TypeGroup:       uint16(transaction.TypeGroup),
+				Amount:          uint64(transaction.Amount),
+				Fee:             uint64(transaction.Fee),
+				Sender:          sender.ToAddress(),
+				SenderPublicKey: transaction.SenderPublicKey,
+				Recipient:       transaction.RecipientId,
+				Signature:       transaction.Signature,
+				VendorField:     transaction.VendorField,
+				Nonce:           transaction.Nonce,
+			}}, txs...)
+			id, _ := hex.DecodeString(transaction.Id)
+			ids = append(ids, id)
+
+			block.Forged.Amount += uint64(transaction.Amount)
+			block.Forged.Fee += uint64(transaction.Fee)
+		}
+		payloadHash := sha256.Sum256(bytes.Join(ids, nil))
+		block.Payload = client.BlockPayload{Hash: hex.EncodeToString(payloadHash[:]), Length: uint32(32 * txsPerBlock)}
+
+		header, err := serializeBlockHeader(&block)
+		if err != nil {
+			t.Fatalf("serialize block header error: %v", err)
+		}
+		hash := sha256.Sum256(header)
+		signature, _ := generator.SignECDSA(hash[:])
+		block.Signature = hex.EncodeToString(signature)
+		id := sha256.Sum256(append(header, signature...))
+		block.Id = hex.EncodeToString(id[:])
+
+		for i := range txs {
+			txs[i].BlockId = block.Id
+		}
+		chain.blocks = append(chain.blocks, block)
+		chain.txs[block.Id] = txs
+	}
+	return chain
+}
+
+func TestARKBlockScanner_VerifyBlock(t *testing.T) {
+	wm := NewWalletManager()
+	chain := newSignedTestChain(t, wm.Config.Crypto, 2, 3)
+	block := chain.blocks[1]
+	txs := chain.txs[block.Id]
+
+	if err := wm.Blockscanner.verifyBlock(&block, txs); err != nil {
+		t.Fatalf("verify signed block error: %v", err)
+	}
+
+	tamper := func(name string, change func(block *client.Block, txs []client.Transaction)) {
+		forgedBlock := block
+		forgedTxs := append([]client.Transaction{}, txs...)
+		change(&forgedBlock, forgedTxs)
+		err := wm.Blockscanner.verifyBlock(&forgedBlock, forgedTxs)
+		if _, ok := err.(*BlockVerifyError); !ok {
+			t.Errorf("%s: verify got %v, want BlockVerifyError", name, err)
+		}
+	}
+
+	tamper("amount", func(block *client.Block, txs []client.Transaction) {
+		txs[0].Amount = 9900000000
+	})
+	tamper("recipient", func(block *client.Block, txs []client.Transaction) {
+		txs[1].Recipient = "AUexKjGtgsSpVzPLs6jNMM6vJ6znEVTQWK"
+	})
+	tamper("signature", func(block *client.Block, txs []client.Transaction) {
+		//交易ID与篡改后的内容一致，但签名无效
+		txs[0].Amount = 9900000000
+		transaction, _ := wm.toCryptoTransaction(&txs[0])
+		txs[0].Id = wm.Config.Crypto.GetId(transaction)
+	})
+	tamper("missing transaction", func(block *client.Block, txs []client.Transaction) {
+		block.Transactions = uint32(len(txs) + 1)
+	})
+	tamper("payload", func(block *client.Block, txs []client.Transaction) {
+		txs[0], txs[1] = txs[1], txs[0]
+	})
+	tamper("generator signature", func(block *client.Block, txs []client.Transaction) {
+		block.Forged.Reward = 300000000
+	})
+	tamper("unsupported version", func(block *client.Block, txs []client.Transaction) {
+		txs[2].Version = 1
+	})
+	tamper("invalid hex", func(block *client.Block, txs []client.Transaction) {
+		txs[2].SenderPublicKey = "not hex"
+	})
+}
+
+func TestARKBlockScanner_ScanBlockTaskVerifyHalt(t *testing.T) {
+	wm := NewWalletManager()
+	chain := newSignedTestChain(t, wm.Config.Crypto, 10, 2)
+	//第7个区块的交易被节点篡改
+	forged := chain.blocks[6].Id
+	chain.txs[forged][1].Amount = 9900000000
+
+	bs, dai, observer, closeServer := newTestScanner(chain)
+	defer closeServer()
+	bs.wm.Config.VerifyBlocks = true
+
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 1, Hash: chain.blocks[0].Id})
+	bs.ScanBlockTask()
+
+	if bs.Scanning || bs.HaltError() == nil {
+		t.Fatalf("scanner should halt on forged block, scanning: %v", bs.Scanning)
+	}
+	if dai.head.Height != 6 || dai.head.Hash != chain.blocks[5].Id {
+		t.Errorf("scanned head got %d, want 6", dai.head.Height)
+	}
+	if len(observer.txIDs) != 10 {
+		t.Errorf("notified %d transactions, want 10", len(observer.txIDs))
+	}
+	if len(dai.unscan) != 0 {
+		t.Errorf("forged block should not be recorded for rescan")
+	}
+}
+
+//ARK go-crypto的devnet交易固定向量(crypto/fixtures/transactions)，由ARK Core生成，与本地序列化代码相互独立
+var arkTransactionVectors = []client.Transaction{
+	{
+		Id:              "e65161465cb3a0490e4d0a5f9c128a4d71d7368bb40dc91ac08085fcff92c2c4",
+		Version:         2,
+		Type:            0,
+		TypeGroup:       1,
+		Amount:          200000000,
+		Fee:             10000000,
+		Nonce:           6,
+		Expiration:      4333222,
+		Sender:          "DCWDZzzTMoVF1xHJb1WGy7e2RuzpcCajau",
+		SenderPublicKey: "0236175ac7b308a2d8d60183021d063a5b2e397355d4014a91a2a6cc5c6ace9f33",
+		Recipient:       "DPXaJv1GcVpZPvxw5T4fXebqTVhFpfqyrC",
+		VendorField:     "4sln5yo05",
+		Signature:       "304402200bbb7b5fb4c880e0a7c7105783ab92bfe9b7b630c9a5844fbb9b8c288a7909ce0220212eab02988115e625ca9c70bb642fa20bb9bf416d8e46858c107f66d58ac952",
+	},
+	{
+		Id:              "942ee12b7012914b9408f9b1d665d23965c53cd06a16540b1aa74dfa66294363",
+		Version:         2,
+		Type:            3,
+		TypeGroup:       1,
+		Fee:             10000000,
+		Nonce:           6,
+		Sender:          "DCWDZzzTMoVF1xHJb1WGy7e2RuzpcCajau",
+		SenderPublicKey: "0236175ac7b308a2d8d60183021d063a5b2e397355d4014a91a2a6cc5c6ace9f33",
+		Asset:           &client.TransactionAsset{Votes: []string{"+02aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}},
+		Signature:       "304402202e48d71618e74c935400a7f0d31fdf230f63bb6ebc2d9e544445b9352464f6a802206879d8303c9b6be92e59973f2be36c74d2a6b8db60596f6d49a0a7f409630606",
+	},
+}
+
+func TestARKBlockScanner_VerifyTransactionVectors(t *testing.T) {
+	wm := NewWalletManager()
+	wm.Config.Crypto.SetNetwork(crypto.NETWORKS_DEVNET)
+	bs := wm.Blockscanner
+
+	for _, vector := range arkTransactionVectors {
+		trans := vector
+		if err := bs.verifyTransaction(&trans); err != nil {
+			t.Errorf("verify transaction vector %s error: %v", vector.Id, err)
+		}
+
+		//签名或交易ID的任一字节被篡改都应失败
+		for _, field := range []string{"signature", "id"} {
+			for i := 0; i < 64; i += 7 {
+				mutated := vector
+				target := &mutated.Signature
+				if field == "id" {
+					target = &mutated.Id
+				}
+				raw, _ := hex.DecodeString(*target)
+				raw[len(raw)-1-i/2] ^= 0x01
+				*target = hex.EncodeToString(raw)
+				if err := bs.verifyTransaction(&mutated); err == nil {
+					t.Errorf("transaction vector %s with mutated %s byte %d should fail", vector.Id, field, i/2)
+				}
+			}
+		}
+
+		mutated := vector
+		mutated.Fee++
+		if err := bs.verifyTransaction(&mutated); err == nil {
+			t.Errorf("transaction vector %s with mutated fee should fail", vector.Id)
+		}
+
+		//节点返回的发送者地址与公钥不符
+		mutated = vector
+		mutated.Sender = testWatchedAddress
+		if err := bs.verifyTransaction(&mutated); err == nil {
+			t.Errorf("transaction vector %s with mutated sender should fail", vector.Id)
+		}
+	}
+}
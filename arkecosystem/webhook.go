@@ -0,0 +1,170 @@
+package arkecosystem
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"github.com/blocktree/arkecosystem-adapter/sdk/client"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	//默认webhook接收路径
+	defaultWebhookPath = "/webhook"
+	//节点生成的完整token长度，推送时Authorization只携带前半部分
+	webhookFullTokenLen = 64
+	//webhook请求体大小上限
+	maxWebhookBodySize = 1 << 20
+)
+
+//触发扫描的webhook事件
+var webhookScanEvents = map[string]bool{
+	"block.forged":        true,
+	"block.applied":       true,
+	"transaction.applied": true,
+}
+
+//webhookReceiver 内嵌的webhook接收服务，收到新区块或新交易的推送后立即触发一次扫描
+type webhookReceiver struct {
+	mu       sync.Mutex
+	server   *http.Server
+	listener net.Listener
+	trigger  chan struct{}
+	stop     chan struct{}
+}
+
+//WebhookHandler 校验token并处理ARK Core推送的webhook，可挂载到调用方自己的http服务
+func (bs *ARKBlockScanner) WebhookHandler(token string) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+
+		if request.Method != http.MethodPost {
+			http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if !verifyWebhookToken(token, request.Header.Get("Authorization")) {
+			bs.wm.Log.Std.Warning("webhook receiver rejected request from %s; invalid token", request.RemoteAddr)
+			http.Error(writer, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var payload client.WebhookPayload
+		err := json.NewDecoder(http.MaxBytesReader(writer, request.Body, maxWebhookBodySize)).Decode(&payload)
+		if err != nil {
+			http.Error(writer, "invalid payload", http.StatusBadRequest)
+			return
+		}
+
+		if webhookScanEvents[payload.Event] {
+			bs.wm.Log.Std.Debug("webhook receiver got event [%s], trigger block scanning", payload.Event)
+			bs.TriggerScan()
+		}
+
+		writer.WriteHeader(http.StatusOK)
+	})
+}
+
+//verifyWebhookToken 校验推送的Authorization，节点只推送完整token的前32个字符
+func verifyWebhookToken(token, authorization string) bool {
+	if len(token) == 0 || len(authorization) == 0 {
+		return false
+	}
+	if len(token) == webhookFullTokenLen && len(authorization) == webhookFullTokenLen/2 {
+		token = token[:webhookFullTokenLen/2]
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(authorization)) == 1
+}
+
+//TriggerScan 立即触发一次区块扫描，正在扫描时合并为扫描结束后再执行一次，需先启动webhook接收服务
+func (bs *ARKBlockScanner) TriggerScan() {
+	bs.webhook.mu.Lock()
+	trigger := bs.webhook.trigger
+	bs.webhook.mu.Unlock()
+	if trigger == nil {
+		return
+	}
+	select {
+	case trigger <- struct{}{}:
+	default:
+	}
+}
+
+//StartWebhookReceiver 启动webhook接收服务，定时扫描作为推送丢失时的兜底
+func (bs *ARKBlockScanner) StartWebhookReceiver(addr, path, token string) error {
+	bs.StopWebhookReceiver()
+
+	if len(token) == 0 {
+		return fmt.Errorf("webhook token is required by the webhook receiver")
+	}
+	if len(path) == 0 {
+		path = defaultWebhookPath
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("webhook receiver can not listen on %s, unexpected error: %v", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, bs.WebhookHandler(token))
+	server := &http.Server{Handler: mux, ReadTimeout: 30 * time.Second, WriteTimeout: 30 * time.Second}
+	trigger := make(chan struct{}, 1)
+	stop := make(chan struct{})
+
+	bs.webhook.mu.Lock()
+	bs.webhook.server = server
+	bs.webhook.listener = listener
+	bs.webhook.trigger = trigger
+	bs.webhook.stop = stop
+	bs.webhook.mu.Unlock()
+
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			bs.wm.Log.Std.Error("webhook receiver stopped; unexpected error: %v", err)
+		}
+	}()
+
+	go func() {
+		for {
+			select {
+			case <-trigger:
+				if bs.Scanning {
+					bs.ScanBlockTask()
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	bs.wm.Log.Std.Info("webhook receiver is listening on %s%s", listener.Addr(), path)
+	return nil
+}
+
+//WebhookAddr webhook接收服务实际监听的地址，未启动时返回空
+func (bs *ARKBlockScanner) WebhookAddr() string {
+	bs.webhook.mu.Lock()
+	defer bs.webhook.mu.Unlock()
+	if bs.webhook.listener == nil {
+		return ""
+	}
+	return bs.webhook.listener.Addr().String()
+}
+
+//StopWebhookReceiver 停止webhook接收服务
+func (bs *ARKBlockScanner) StopWebhookReceiver() {
+	bs.webhook.mu.Lock()
+	defer bs.webhook.mu.Unlock()
+	if bs.webhook.server != nil {
+		bs.webhook.server.Close()
+		close(bs.webhook.stop)
+	}
+	bs.webhook.server = nil
+	bs.webhook.listener = nil
+	bs.webhook.trigger = nil
+	bs.webhook.stop = nil
+}
@@ -0,0 +1,88 @@
+package arkecosystem
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blocktree/openwallet/v2/openwallet"
+)
+
+const testWebhookToken = "a5b8c52b8dd5b8f1e0a3c3d27ec7a4e0f2b6d9e1c4a7f0b3d6e9c2f5a8b1d4e7"
+
+func TestVerifyWebhookToken(t *testing.T) {
+	tests := []struct {
+		token         string
+		authorization string
+		valid         bool
+	}{
+		{token: testWebhookToken, authorization: testWebhookToken[:32], valid: true},
+		{token: testWebhookToken, authorization: testWebhookToken, valid: true},
+		{token: testWebhookToken, authorization: testWebhookToken[32:], valid: false},
+		{token: "custom-token", authorization: "custom-token", valid: true},
+		{token: "custom-token", authorization: "", valid: false},
+		{token: "", authorization: "", valid: false},
+	}
+	for i, test := range tests {
+		if got := verifyWebhookToken(test.token, test.authorization); got != test.valid {
+			t.Errorf("test %d verify token got %v, want %v", i, got, test.valid)
+		}
+	}
+}
+
+func TestARKBlockScanner_WebhookReceiver(t *testing.T) {
+	chain := newTestChain(10, 1)
+	bs, dai, observer, closeServer := newTestScanner(chain)
+	defer closeServer()
+	dai.SaveCurrentBlockHead(&openwallet.BlockHeader{Height: 1, Hash: "block-1"})
+
+	if err := bs.StartWebhookReceiver("127.0.0.1:0", "/webhook", testWebhookToken); err != nil {
+		t.Fatalf("start webhook receiver error: %v", err)
+	}
+	defer bs.StopWebhookReceiver()
+	target := "http://" + bs.WebhookAddr() + "/webhook"
+
+	post := func(authorization, body string) int {
+		req, _ := http.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set("Authorization", authorization)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("post webhook error: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := post("wrong-token", `{"event": "block.forged"}`); code != http.StatusUnauthorized {
+		t.Errorf("webhook with wrong token got status %d", code)
+	}
+	if code := post(testWebhookToken[:32], `not json`); code != http.StatusBadRequest {
+		t.Errorf("webhook with invalid payload got status %d", code)
+	}
+	if head, _ := dai.GetCurrentBlockHead(Symbol); head.Height != 1 {
+		t.Fatalf("rejected webhooks should not trigger scanning, head %d", head.Height)
+	}
+
+	if code := post(testWebhookToken[:32], `{"event": "block.forged", "timestamp": 1572012480, "data": {"height": 10}}`); code != http.StatusOK {
+		t.Errorf("webhook got status %d", code)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if head, _ := dai.GetCurrentBlockHead(Symbol); head.Height == 10 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if head, _ := dai.GetCurrentBlockHead(Symbol); head.Height != 10 {
+		t.Fatalf("webhook should trigger scanning, head %d", head.Height)
+	}
+	bs.StopWebhookReceiver()
+
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	if len(observer.txIDs) != 9 {
+		t.Errorf("notified %d transactions, want 9", len(observer.txIDs))
+	}
+}
@@ -1,42 +1,37 @@
 package arkecosystem_addrec
 
 import (
-	"github.com/blocktree/arkecosystem-adapter/sdk/crypto2"
-	"github.com/blocktree/openwallet/v2/log"
+	"github.com/blocktree/arkecosystem-adapter/sdk/crypto"
 	"github.com/blocktree/openwallet/v2/openwallet"
 )
 
 var (
-	Default = AddressDecoderV2{}
+	//Default 使用sdk/crypto默认网络(mainnet)的地址解析器，其他网络使用NewAddressDecoderV2
+	Default = NewAddressDecoderV2(crypto.DefaultConfig)
 )
 
 //AddressDecoderV2
 type AddressDecoderV2 struct {
 	*openwallet.AddressDecoderV2Base
+	config *crypto.Config
 }
 
-// GetAddressFromPublicKey takes a Lisk public key and returns the associated address
-func GetAddressFromPublicKey(publicKey []byte) string {
-	//publicKeyHash := sha256.Sum256(publicKey)
-
-	pk, err := crypto2.PublicKeyFromBytes(publicKey)
-	if err != nil {
-		log.Error(err)
-	}
-	return pk.ToAddress()
-
+//NewAddressDecoderV2 按网络配置创建地址解析器，传入钱包管理者的wm.Config.Crypto以使用配置的网络
+func NewAddressDecoderV2(config *crypto.Config) *AddressDecoderV2 {
+	return &AddressDecoderV2{config: config}
 }
 
 //AddressEncode 地址编码
 func (dec *AddressDecoderV2) AddressEncode(hash []byte, opts ...interface{}) (string, error) {
-	address := GetAddressFromPublicKey(hash)
-	return address, nil
+	publicKey, err := dec.config.PublicKeyFromBytes(hash)
+	if err != nil {
+		return "", err
+	}
+	return publicKey.ToAddress(), nil
 }
 
-
-
 // AddressVerify 地址校验
 func (dec *AddressDecoderV2) AddressVerify(address string, opts ...interface{}) bool {
-
-	return true
+	valid, _ := dec.config.ValidateAddress(address)
+	return valid
 }
@@ -1,8 +1,10 @@
 package arkecosystem_addrec
 
 import (
+	"encoding/hex"
 	"fmt"
 	"github.com/ArkEcosystem/go-crypto/crypto"
+	sdkcrypto "github.com/blocktree/arkecosystem-adapter/sdk/crypto"
 	"testing"
 )
 
@@ -10,4 +12,25 @@ import (
 func TestAdmSymbolList(t * testing.T){
 	address, _ := crypto.AddressFromPassphrase("")
 	fmt.Println(address)
-}
\ No newline at end of file
+}
+
+func TestAddressDecoderV2_Network(t *testing.T) {
+	pub, _ := hex.DecodeString("034151a3ec46b5670a682b0a63394f863587d1bc97483b1b6c70eb58e7f0aed192")
+	mainnet := Default
+	devnet := NewAddressDecoderV2(sdkcrypto.NewConfig(sdkcrypto.NETWORKS_DEVNET))
+
+	mainnetAddr, err := mainnet.AddressEncode(pub)
+	if err != nil {
+		t.Fatalf("AddressEncode error: %v", err)
+	}
+	devnetAddr, err := devnet.AddressEncode(pub)
+	if err != nil {
+		t.Fatalf("AddressEncode error: %v", err)
+	}
+	if mainnetAddr[0] != 'A' || devnetAddr[0] != 'D' {
+		t.Errorf("addresses got %s, %s", mainnetAddr, devnetAddr)
+	}
+	if !devnet.AddressVerify(devnetAddr) || devnet.AddressVerify(mainnetAddr) || devnet.AddressVerify("D-invalid") {
+		t.Errorf("AddressVerify should only accept addresses of the configured network")
+	}
+}
@@ -6,6 +6,7 @@ require (
 	github.com/ArkEcosystem/go-crypto v0.0.0-20200210042049-1901e1f9967a
 	github.com/DataDog/zstd v1.4.5 // indirect
 	github.com/Sereal/Sereal v0.0.0-20200417095951-15946cd26aa7 // indirect
+	github.com/asdine/storm v2.1.2+incompatible
 	github.com/astaxie/beego v1.12.0
 	github.com/blocktree/go-owcdrivers v1.2.1
 	github.com/blocktree/go-owcrypt v1.1.4
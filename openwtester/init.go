@@ -12,4 +12,8 @@ func init() {
 	// openw.RegAssets(eosio.Symbol, eosio.NewWalletManager(nil))
 
 	openw.RegAssets(arkecosystem.Symbol, arkecosystem.NewWalletManager())
+
+	// ARK衍生链使用独立的链参数注册，例如:
+	// bridgechain, _ := arkecosystem.NewWalletManagerWithChain(arkecosystem.ChainParams{...})
+	// openw.RegAssets(bridgechain.Symbol(), bridgechain)
 }
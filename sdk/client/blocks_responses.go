@@ -37,7 +37,7 @@ type Block struct {
 	Generator     BlockGenerator `json:"generator,omitempty"`
 	Signature     string         `json:"signature,omitempty"`
 	Confirmations uint32         `json:"confirmations,omitempty"`
-	Transactions  byte           `json:"transactions,omitempty"`
+	Transactions  uint32         `json:"transactions,omitempty"`
 	Timestamp     Timestamp      `json:"timestamp,omitempty"`
 }
 
@@ -42,6 +42,7 @@ type Client struct {
 	Transactions *TransactionsService
 	Votes        *VotesService
 	Wallets      *WalletsService
+	Webhooks     *WebhooksService
 }
 
 type Service struct {
@@ -69,6 +70,7 @@ func NewClient(httpClient *http.Client) *Client {
 	c.Transactions = (*TransactionsService)(&c.common)
 	c.Votes = (*VotesService)(&c.common)
 	c.Wallets = (*WalletsService)(&c.common)
+	c.Webhooks = (*WebhooksService)(&c.common)
 
 	return c
 }
@@ -98,6 +100,8 @@ func (c *Client) SendRequest(ctx context.Context, method string, urlStr string,
 		return nil, err
 	}
 
+	req = req.WithContext(ctx)
+
 	if queryString != nil {
 		switch v := queryString.(type) {
 		case *Pagination:
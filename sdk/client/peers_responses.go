@@ -7,15 +7,15 @@
 
 package client
 
-type PeerPorts map[string]int16
+type PeerPorts map[string]int
 
 type Peer struct {
 	Ip      string    `json:"ip,omitempty"`
-	Port    int16     `json:"port,omitempty"`
+	Port    int       `json:"port,omitempty"`
 	Ports   PeerPorts `json:"ports,omitempty"`
 	Version string    `json:"version,omitempty"`
 	Height  int64     `json:"height,omitempty"`
-	Latency byte      `json:"latency,omitempty"`
+	Latency int       `json:"latency,omitempty"`
 }
 
 type Peers struct {
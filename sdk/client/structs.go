@@ -9,5 +9,5 @@ package client
 
 type FromTo struct {
 	From interface{} `json:"from,omitempty"`
-	To   interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
 }
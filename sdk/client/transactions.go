@@ -30,8 +30,8 @@ func (s *TransactionsService) List(ctx context.Context, query *Pagination) (*Tra
 }
 
 // Create a new transaction.
-func (s *TransactionsService) Create(ctx context.Context, body *CreateTransactionRequest) (*CreateTransaction, *http.Response, error) {
-	var responseStruct *CreateTransaction
+func (s *TransactionsService) Create(ctx context.Context, body *CreateTransactionRequest) (*GetCreateTransaction, *http.Response, error) {
+	var responseStruct *GetCreateTransaction
 	resp, err := s.client.SendRequest(ctx, "POST", "transactions", nil, body, &responseStruct)
 
 	if err != nil {
@@ -50,7 +50,9 @@ type PaginationBlock struct {
 // Get all transactions.
 func (s *TransactionsService) ListByBlockId(ctx context.Context, query *PaginationBlock) (*Transactions, *http.Response, error) {
 	var responseStruct *Transactions
-	query.Page = 1
+	if query.Page <= 0 {
+		query.Page = 1
+	}
 	resp, err := s.client.SendRequest(ctx, "GET", "transactions", query, nil, &responseStruct)
 
 	if err != nil {
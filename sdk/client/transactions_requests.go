@@ -17,7 +17,7 @@ type TransactionsSearchRequest struct {
 	SenderId        string  `json:"senderId,omitempty"`
 	BlockId         string  `json:"blockId,omitempty"`
 	Type            byte    `json:"type,omitempty"`
-	TypeGroup       uint16  `json:"type,omitempty"`
+	TypeGroup       uint16  `json:"typeGroup,omitempty"`
 	Version         byte    `json:"version,omitempty"`
 	SenderPublicKey string  `json:"senderPublicKey,omitempty"`
 	RecipientId     string  `json:"recipientId,omitempty"`
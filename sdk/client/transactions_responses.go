@@ -8,6 +8,7 @@
 package client
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -24,25 +25,35 @@ type Transaction struct {
 	SenderPublicKey string            `json:"senderPublicKey,omitempty"`
 	Recipient       string            `json:"recipient,omitempty"`
 	Signature       string            `json:"signature,omitempty"`
+	SignSignature   string            `json:"signSignature,omitempty"`
+	Signatures      []string          `json:"signatures,omitempty"`
 	Asset           *TransactionAsset `json:"asset,omitempty"`
 	VendorField     string            `json:"vendorField,omitempty"`
+	VendorFieldHex  string            `json:"vendorFieldHex,omitempty"`
 	Confirmations   uint32            `json:"confirmations,omitempty"`
 	Timestamp       Timestamp         `json:"timestamp,omitempty"`
 	Nonce           uint64            `json:"nonce,omitempty,string"`
+	Expiration      uint32            `json:"expiration,omitempty"`
 }
 
 type Transaction2 struct {
-	Id              string            `json:"id,omitempty"`
-	BlockId         string            `json:"blockId,omitempty"`
-	Version         uint16            `json:"version,omitempty"`
-	Type            uint16            `json:"type"`
-	TypeGroup       uint16            `json:"typeGroup,omitempty"`
-	Amount          uint64            `json:"amount,omitempty,string"`
-	Fee             uint64            `json:"fee,omitempty,string"`
-	SenderPublicKey string            `json:"senderPublicKey,omitempty"`
-	RecipientId     string            `json:"recipientId,omitempty"`
-	Signature       string            `json:"signature,omitempty"`
-	Nonce           uint64            `json:"nonce,omitempty,string"`
+	Id              string      `json:"id,omitempty"`
+	BlockId         string      `json:"blockId,omitempty"`
+	Version         uint16      `json:"version,omitempty"`
+	Network         byte        `json:"network,omitempty"`
+	Type            uint16      `json:"type"`
+	TypeGroup       uint16      `json:"typeGroup,omitempty"`
+	Amount          uint64      `json:"amount,string"`
+	Fee             uint64      `json:"fee,omitempty,string"`
+	SenderPublicKey string      `json:"senderPublicKey,omitempty"`
+	RecipientId     string      `json:"recipientId,omitempty"`
+	Signature       string      `json:"signature,omitempty"`
+	SecondSignature string      `json:"secondSignature,omitempty"`
+	Signatures      []string    `json:"signatures,omitempty"`
+	Asset           interface{} `json:"asset,omitempty"`
+	VendorField     string      `json:"vendorField,omitempty"`
+	Expiration      uint32      `json:"expiration,omitempty"`
+	Nonce           uint64      `json:"nonce,omitempty,string"`
 }
 
 type Transactions struct {
@@ -55,7 +66,13 @@ type GetTransaction struct {
 }
 
 type GetCreateTransaction struct {
-	Data CreateTransaction `json:"data,omitempty"`
+	Data   CreateTransaction             `json:"data,omitempty"`
+	Errors map[string][]TransactionError `json:"errors,omitempty"`
+}
+
+type TransactionError struct {
+	Type    string `json:"type,omitempty"`
+	Message string `json:"message,omitempty"`
 }
 
 type TypeGroupTypes map[string]byte
@@ -81,9 +98,10 @@ func (t Timestamp) Time() time.Time {
 }
 
 type CreateTransaction struct {
-	Accept  []string `json:"accept,omitempty"`
-	Excess  []string `json:"excess,omitempty"`
-	Invalid []string `json:"invalid,omitempty"`
+	Accept    []string `json:"accept,omitempty"`
+	Broadcast []string `json:"broadcast,omitempty"`
+	Excess    []string `json:"excess,omitempty"`
+	Invalid   []string `json:"invalid,omitempty"`
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -93,11 +111,13 @@ type CreateTransaction struct {
 type TransactionAsset struct {
 	Votes          []string                          `json:"votes,omitempty"`
 	Signature      *SecondSignatureRegistrationAsset `json:"signature,omitempty"`
-	Delegate       *DelegateAsset                    `json:"publicKey,omitempty"`
+	Delegate       *DelegateAsset                    `json:"delegate,omitempty"`
 	MultiSignature *MultiSignatureRegistrationAsset  `json:"multisignature,omitempty"`
 	Ipfs           *IpfsAsset                        `json:"ipfs,omitempty"`
 	Payments       []*MultiPaymentAsset              `json:"payments,omitempty"`
+	Lock           *LockAsset                        `json:"lock,omitempty"`
 	Claim          *ClaimAsset                       `json:"claim,omitempty"`
+	Refund         *RefundAsset                      `json:"refund,omitempty"`
 }
 
 type SecondSignatureRegistrationAsset struct {
@@ -109,21 +129,47 @@ type DelegateAsset struct {
 }
 
 type MultiSignatureRegistrationAsset struct {
-	Min       byte     `json:"min,omitempty"`
-	Keysgroup []string `json:"keysgroup,omitempty"`
-	Lifetime  byte     `json:"lifetime,omitempty"`
+	Min        byte     `json:"min,omitempty"`
+	PublicKeys []string `json:"publicKeys,omitempty"`
+	Keysgroup  []string `json:"keysgroup,omitempty"`
+	Lifetime   byte     `json:"lifetime,omitempty"`
 }
 
 type IpfsAsset struct {
 	Dag string `json:"dag,omitempty"`
 }
 
+// UnmarshalJSON accepts both the v2 form, where the asset is the IPFS hash
+// itself, and the legacy object form.
+func (a *IpfsAsset) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &a.Dag)
+	}
+
+	type ipfsAsset IpfsAsset
+	return json.Unmarshal(data, (*ipfsAsset)(a))
+}
+
 type MultiPaymentAsset struct {
 	Amount      uint64 `json:"amount,omitempty,string"`
 	RecipientId string `json:"recipientId,omitempty"`
 }
 
+type LockAsset struct {
+	SecretHash string              `json:"secretHash,omitempty"`
+	Expiration LockExpirationAsset `json:"expiration,omitempty"`
+}
+
+type LockExpirationAsset struct {
+	Type  byte   `json:"type,omitempty"`
+	Value uint32 `json:"value,omitempty"`
+}
+
 type ClaimAsset struct {
 	LockTransactionId string `json:"lockTransactionId,omitempty"`
 	UnlockSecret      string `json:"unlockSecret,omitempty"`
 }
+
+type RefundAsset struct {
+	LockTransactionId string `json:"lockTransactionId,omitempty"`
+}
@@ -98,45 +98,49 @@ func TestTransactionsService_List(t *testing.T) {
 
 // Create a new transaction.
 func TestTransactionsService_Create(t *testing.T) {
-	t.Skip("test not ready")
 	client, mux, _, teardown := setupTest()
 	defer teardown()
 
 	mux.HandleFunc("/transactions", func(writer http.ResponseWriter, request *http.Request) {
 		testMethod(t, request, "POST")
-		testJsonPayload(t, request, values{"limit": 1})
+		testJsonPayload(t, request, values{})
 		fmt.Fprint(writer,
 			`{
 			  "data": {
 			    "accept": [
 			    	"dummy"
 			    ],
+			    "broadcast": [
+			    	"dummy"
+			    ],
 			    "excess": [],
-			    "invalid": []
+			    "invalid": [
+			    	"invalid"
+			    ]
+			  },
+			  "errors": {
+			    "invalid": [
+			      {
+			        "type": "ERR_BAD_DATA",
+			        "message": "Transaction didn't pass the verification process."
+			      }
+			    ]
 			  }
 			}`)
 	})
 
 	body := &CreateTransactionRequest{
-		Transactions: []Transaction{{
+		Transactions: []Transaction2{{
 			Id:              "dummy",
-			BlockId:         "dummy",
 			Type:            0,
 			TypeGroup:       1,
 			Amount:          10000000,
 			Fee:             10000000,
-			Sender:          "dummy",
 			SenderPublicKey: "dummy",
-			Recipient:       "dummy",
+			RecipientId:     "dummy",
 			Signature:       "dummy",
 			VendorField:     "dummy",
-			Confirmations:   10,
-			Timestamp: Timestamp{
-				Epoch: 40505460,
-				Unix:  1530606660,
-				Human: "2018-07-03T08:31:00Z",
-			},
-			Nonce: 1,
+			Nonce:           1,
 		}},
 	}
 	responseStruct, response, err := client.Transactions.Create(context.Background(), body)
@@ -147,8 +151,19 @@ func TestTransactionsService_Create(t *testing.T) {
 			Accept: []string{
 				"dummy",
 			},
-			Excess:  []string{},
-			Invalid: []string{},
+			Broadcast: []string{
+				"dummy",
+			},
+			Excess: []string{},
+			Invalid: []string{
+				"invalid",
+			},
+		},
+		Errors: map[string][]TransactionError{
+			"invalid": {{
+				Type:    "ERR_BAD_DATA",
+				Message: "Transaction didn't pass the verification process.",
+			}},
 		},
 	})
 }
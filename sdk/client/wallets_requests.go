@@ -8,14 +8,15 @@
 package client
 
 type WalletsSearchRequest struct {
-	OrderBy         string  `json:"orderBy,omitempty"`
-	Address         string  `json:"address,omitempty"`
-	PublicKey       string  `json:"publicKey,omitempty"`
-	SecondPublicKey string  `json:"secondPublicKey,omitempty"`
-	Vote            string  `json:"vote,omitempty"`
-	Username        string  `json:"username,omitempty"`
-	ProducedBlocks  uint32  `json:"producedBlocks,omitempty"`
-	MissedBlocks    uint32  `json:"missedBlocks,omitempty"`
-	Balance         *FromTo `json:"balance ,omitempty"`
-	VoteBalance     *FromTo `json:"voteBalance ,omitempty"`
+	OrderBy         string   `json:"orderBy,omitempty"`
+	Address         string   `json:"address,omitempty"`
+	Addresses       []string `json:"addresses,omitempty"`
+	PublicKey       string   `json:"publicKey,omitempty"`
+	SecondPublicKey string   `json:"secondPublicKey,omitempty"`
+	Vote            string   `json:"vote,omitempty"`
+	Username        string   `json:"username,omitempty"`
+	ProducedBlocks  uint32   `json:"producedBlocks,omitempty"`
+	MissedBlocks    uint32   `json:"missedBlocks,omitempty"`
+	Balance         *FromTo  `json:"balance ,omitempty"`
+	VoteBalance     *FromTo  `json:"voteBalance ,omitempty"`
 }
@@ -8,11 +8,22 @@
 package client
 
 type Wallet struct {
-	Address    string `json:"address,omitempty"`
-	PublicKey  string `json:"publicKey,omitempty"`
-	Nonce      uint64 `json:"nonce,omitempty,string"`
-	Balance    uint64 `json:"balance,omitempty,string"`
-	IsDelegate bool   `json:"isDelegate,omitempty"`
+	Address    string            `json:"address,omitempty"`
+	PublicKey  string            `json:"publicKey,omitempty"`
+	Nonce      uint64            `json:"nonce,omitempty,string"`
+	Balance    uint64            `json:"balance,omitempty,string"`
+	IsDelegate bool              `json:"isDelegate,omitempty"`
+	Attributes *WalletAttributes `json:"attributes,omitempty"`
+}
+
+type WalletAttributes struct {
+	SecondPublicKey string                           `json:"secondPublicKey,omitempty"`
+	MultiSignature  *MultiSignatureRegistrationAsset `json:"multiSignature,omitempty"`
+	Htlc            *WalletHtlcAttributes            `json:"htlc,omitempty"`
+}
+
+type WalletHtlcAttributes struct {
+	LockedBalance uint64 `json:"lockedBalance,omitempty,string"`
 }
 
 type Wallets struct {
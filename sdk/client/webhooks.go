@@ -0,0 +1,74 @@
+// This file is part of Ark Go Client.
+//
+// (c) Ark Ecosystem <info@ark.io>
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// WebhooksService handles communication with the webhooks related
+// methods of the Ark Core Webhooks API, which is served on its own port
+// (4004 by default), so the client BaseURL has to point to that server.
+type WebhooksService Service
+
+// Get all webhooks.
+func (s *WebhooksService) List(ctx context.Context, query *Pagination) (*Webhooks, *http.Response, error) {
+	var responseStruct *Webhooks
+	resp, err := s.client.SendRequest(ctx, "GET", "webhooks", query, nil, &responseStruct)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return responseStruct, resp, err
+}
+
+// Register a new webhook. The full token is only returned by this call.
+func (s *WebhooksService) Create(ctx context.Context, body *CreateWebhookRequest) (*GetWebhook, *http.Response, error) {
+	var responseStruct *GetWebhook
+	resp, err := s.client.SendRequest(ctx, "POST", "webhooks", nil, body, &responseStruct)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return responseStruct, resp, err
+}
+
+// Get a webhook by the given id.
+func (s *WebhooksService) Get(ctx context.Context, id string) (*GetWebhook, *http.Response, error) {
+	uri := fmt.Sprintf("webhooks/%v", id)
+
+	var responseStruct *GetWebhook
+	resp, err := s.client.SendRequest(ctx, "GET", uri, nil, nil, &responseStruct)
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return responseStruct, resp, err
+}
+
+// Delete a webhook by the given id.
+func (s *WebhooksService) Delete(ctx context.Context, id string) (*http.Response, error) {
+	uri := fmt.Sprintf("webhooks/%v", id)
+
+	resp, err := s.client.SendRequest(ctx, "DELETE", uri, nil, nil, nil)
+
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp, fmt.Errorf("delete webhook %v failed: %s", id, resp.Status)
+	}
+
+	return resp, err
+}
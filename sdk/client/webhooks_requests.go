@@ -0,0 +1,15 @@
+// This file is part of Ark Go Client.
+//
+// (c) Ark Ecosystem <info@ark.io>
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package client
+
+type CreateWebhookRequest struct {
+	Event      string             `json:"event"`
+	Target     string             `json:"target"`
+	Enabled    bool               `json:"enabled"`
+	Conditions []WebhookCondition `json:"conditions,omitempty"`
+}
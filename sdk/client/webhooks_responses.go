@@ -0,0 +1,41 @@
+// This file is part of Ark Go Client.
+//
+// (c) Ark Ecosystem <info@ark.io>
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package client
+
+import "encoding/json"
+
+type WebhookCondition struct {
+	Key       string `json:"key,omitempty"`
+	Condition string `json:"condition,omitempty"`
+	Value     string `json:"value,omitempty"`
+}
+
+type Webhook struct {
+	Id         string             `json:"id,omitempty"`
+	Event      string             `json:"event,omitempty"`
+	Target     string             `json:"target,omitempty"`
+	Token      string             `json:"token,omitempty"`
+	Enabled    bool               `json:"enabled,omitempty"`
+	Conditions []WebhookCondition `json:"conditions,omitempty"`
+}
+
+type Webhooks struct {
+	Meta Meta      `json:"meta,omitempty"`
+	Data []Webhook `json:"data,omitempty"`
+}
+
+type GetWebhook struct {
+	Data Webhook `json:"data,omitempty"`
+}
+
+// WebhookPayload is the body POSTed by Ark Core to the target of a webhook.
+type WebhookPayload struct {
+	Event     string          `json:"event,omitempty"`
+	Timestamp int64           `json:"timestamp,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
@@ -0,0 +1,172 @@
+// This file is part of Ark Go Client.
+//
+// (c) Ark Ecosystem <info@ark.io>
+//
+// For the full copyright and license information, please view the LICENSE
+// file that was distributed with this source code.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// Get all webhooks.
+func TestWebhooksService_List(t *testing.T) {
+	client, mux, _, teardown := setupTest()
+	defer teardown()
+
+	mux.HandleFunc("/webhooks", func(writer http.ResponseWriter, request *http.Request) {
+		testMethod(t, request, "GET")
+		fmt.Fprint(writer,
+			`{
+			  "meta": {
+			    "count": 1,
+			    "pageCount": 1,
+			    "totalCount": 1,
+			    "next": null,
+			    "previous": null,
+			    "self": "/api/webhooks?page=1&limit=1",
+			    "first": "/api/webhooks?page=1&limit=1",
+			    "last": "/api/webhooks?page=1&limit=1"
+			  },
+			  "data": [
+			    {
+			      "id": "dummyId",
+			      "event": "block.forged",
+			      "target": "http://127.0.0.1:4100/webhook",
+			      "enabled": true,
+			      "conditions": []
+			    }
+			  ]
+			}`)
+	})
+
+	query := &Pagination{Limit: 1}
+	responseStruct, response, err := client.Webhooks.List(context.Background(), query)
+	testGeneralError(t, "Webhooks.List", err)
+	testResponseUrl(t, "Webhooks.List", response, "/webhooks")
+	testResponseStruct(t, "Webhooks.List", responseStruct, &Webhooks{
+		Meta: Meta{
+			Count:      1,
+			PageCount:  1,
+			TotalCount: 1,
+			Next:       "",
+			Previous:   "",
+			Self:       "/api/webhooks?page=1&limit=1",
+			First:      "/api/webhooks?page=1&limit=1",
+			Last:       "/api/webhooks?page=1&limit=1",
+		},
+		Data: []Webhook{{
+			Id:         "dummyId",
+			Event:      "block.forged",
+			Target:     "http://127.0.0.1:4100/webhook",
+			Enabled:    true,
+			Conditions: []WebhookCondition{},
+		}},
+	})
+}
+
+// Register a new webhook.
+func TestWebhooksService_Create(t *testing.T) {
+	client, mux, _, teardown := setupTest()
+	defer teardown()
+
+	mux.HandleFunc("/webhooks", func(writer http.ResponseWriter, request *http.Request) {
+		testMethod(t, request, "POST")
+		var body CreateWebhookRequest
+		json.NewDecoder(request.Body).Decode(&body)
+		if body.Event != "transaction.applied" || body.Target != "http://127.0.0.1:4100/webhook" || !body.Enabled {
+			t.Errorf("Webhooks.Create request body got %+v", body)
+		}
+		writer.WriteHeader(http.StatusCreated)
+		fmt.Fprint(writer,
+			`{
+			  "data": {
+			    "id": "dummyId",
+			    "event": "transaction.applied",
+			    "target": "http://127.0.0.1:4100/webhook",
+			    "token": "dummyToken",
+			    "enabled": true,
+			    "conditions": [{"key": "recipientId", "condition": "eq", "value": "dummyAddress"}]
+			  }
+			}`)
+	})
+
+	body := &CreateWebhookRequest{
+		Event:      "transaction.applied",
+		Target:     "http://127.0.0.1:4100/webhook",
+		Enabled:    true,
+		Conditions: []WebhookCondition{{Key: "recipientId", Condition: "eq", Value: "dummyAddress"}},
+	}
+	responseStruct, response, err := client.Webhooks.Create(context.Background(), body)
+	testGeneralError(t, "Webhooks.Create", err)
+	testResponseUrl(t, "Webhooks.Create", response, "/webhooks")
+	testResponseStruct(t, "Webhooks.Create", responseStruct, &GetWebhook{
+		Data: Webhook{
+			Id:         "dummyId",
+			Event:      "transaction.applied",
+			Target:     "http://127.0.0.1:4100/webhook",
+			Token:      "dummyToken",
+			Enabled:    true,
+			Conditions: []WebhookCondition{{Key: "recipientId", Condition: "eq", Value: "dummyAddress"}},
+		},
+	})
+}
+
+// Get a webhook by the given id.
+func TestWebhooksService_Get(t *testing.T) {
+	client, mux, _, teardown := setupTest()
+	defer teardown()
+
+	mux.HandleFunc("/webhooks/dummyId", func(writer http.ResponseWriter, request *http.Request) {
+		testMethod(t, request, "GET")
+		fmt.Fprint(writer,
+			`{
+			  "data": {
+			    "id": "dummyId",
+			    "event": "block.forged",
+			    "target": "http://127.0.0.1:4100/webhook",
+			    "enabled": false
+			  }
+			}`)
+	})
+
+	responseStruct, response, err := client.Webhooks.Get(context.Background(), "dummyId")
+	testGeneralError(t, "Webhooks.Get", err)
+	testResponseUrl(t, "Webhooks.Get", response, "/webhooks/dummyId")
+	testResponseStruct(t, "Webhooks.Get", responseStruct, &GetWebhook{
+		Data: Webhook{
+			Id:     "dummyId",
+			Event:  "block.forged",
+			Target: "http://127.0.0.1:4100/webhook",
+		},
+	})
+}
+
+// Delete a webhook by the given id.
+func TestWebhooksService_Delete(t *testing.T) {
+	client, mux, _, teardown := setupTest()
+	defer teardown()
+
+	mux.HandleFunc("/webhooks/dummyId", func(writer http.ResponseWriter, request *http.Request) {
+		testMethod(t, request, "DELETE")
+		writer.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/webhooks/unknownId", func(writer http.ResponseWriter, request *http.Request) {
+		http.Error(writer, "", http.StatusNotFound)
+	})
+
+	response, err := client.Webhooks.Delete(context.Background(), "dummyId")
+	testGeneralError(t, "Webhooks.Delete", err)
+	testResponseUrl(t, "Webhooks.Delete", response, "/webhooks/dummyId")
+
+	_, err = client.Webhooks.Delete(context.Background(), "unknownId")
+	if err == nil {
+		t.Errorf("Webhooks.Delete of unknown webhook should fail")
+	}
+}
@@ -14,7 +14,11 @@ import (
 )
 
 func AddressFromPassphrase(passphrase string) (string, error) {
-	privateKey, err := PrivateKeyFromPassphrase(passphrase)
+	return DefaultConfig.AddressFromPassphrase(passphrase)
+}
+
+func (config *Config) AddressFromPassphrase(passphrase string) (string, error) {
+	privateKey, err := config.PrivateKeyFromPassphrase(passphrase)
 
 	if err != nil {
 		return "", err
@@ -24,13 +28,17 @@ func AddressFromPassphrase(passphrase string) (string, error) {
 }
 
 func ValidateAddress(address string) (bool, error) {
+	return DefaultConfig.ValidateAddress(address)
+}
+
+func (config *Config) ValidateAddress(address string) (bool, error) {
 	_, version, err := b58.CheckDecode(address)
 
 	if err != nil {
 		return false, err
 	}
 
-	if GetNetwork().Version != version {
+	if config.GetNetwork().Version != version {
 		return false, errors.New("network version mismatch")
 	}
 
@@ -7,57 +7,61 @@
 
 package crypto
 
-func buildSignedTransaction(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
-	transaction.Sign(passphrase)
+func (config *Config) buildSignedTransaction(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
+	config.SignTransaction(transaction, passphrase)
 
 	if len(secondPassphrase) > 0 {
-		transaction.SecondSign(secondPassphrase)
+		config.SecondSignTransaction(transaction, secondPassphrase)
 	}
 
-	transaction.Id = transaction.GetId()
+	transaction.Id = config.GetId(transaction)
 
 	return transaction
 }
 
-func buildMultiSignedTransaction(transaction *Transaction, signerIndex int, passphrase string) *Transaction {
-	transaction.SignMulti(signerIndex, passphrase)
+func (config *Config) buildMultiSignedTransaction(transaction *Transaction, signerIndex int, passphrase string) *Transaction {
+	config.SignMultiTransaction(transaction, signerIndex, passphrase)
 
-	transaction.Id = transaction.GetId()
+	transaction.Id = config.GetId(transaction)
 
 	return transaction
 }
 
 func BuildTransferMySelf(recipient string, amount FlexToshi, senderpk string, senderid string, nonce uint64) *Transaction {
+	return DefaultConfig.BuildTransferMySelf(recipient, amount, senderpk, senderid, nonce)
+}
+
+func (config *Config) BuildTransferMySelf(recipient string, amount FlexToshi, senderpk string, senderid string, nonce uint64) *Transaction {
 	transaction := &Transaction{
 		SenderPublicKey: senderpk,
 		SenderId:        senderid,
-		Nonce:           nonce + 1,
+		Nonce:           nonce,
 		Amount: amount,
 		RecipientId: recipient,
 	}
 
-	setCommonFields(transaction, TRANSACTION_TYPES.Transfer)
+	config.setCommonFields(transaction, TRANSACTION_TYPES.Transfer)
 
 	transaction.Asset = &TransactionAsset{}
-	transaction.Timestamp = GetTime()
+	transaction.Timestamp = config.GetTime()
 
 	return transaction
 }
 
-func setCommonFields(transaction *Transaction, transactionType uint16) {
+func (config *Config) setCommonFields(transaction *Transaction, transactionType uint16) {
 	if transaction.Fee == 0 {
-		transaction.Fee = GetFee(transactionType)
+		transaction.Fee = config.GetFee(transactionType)
 	}
 
 	if transaction.Network == 0 {
-		transaction.Network = GetNetwork().Version
+		transaction.Network = config.GetNetwork().Version
 	}
 
 	transaction.SecondSenderPublicKey = ""
 	transaction.SecondSignature = ""
 
 	if transaction.Timestamp == 0 {
-		transaction.Timestamp = GetTime()
+		transaction.Timestamp = config.GetTime()
 	}
 
 	transaction.Type = transactionType
@@ -76,11 +80,15 @@ func setCommonFields(transaction *Transaction, transactionType uint16) {
  *   Timestamp - optional, if 0, then it will be set to the present time
  *   VendorField - optional */
 func BuildTransfer(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
-	setCommonFields(transaction, TRANSACTION_TYPES.Transfer)
+	return DefaultConfig.BuildTransfer(transaction, passphrase, secondPassphrase)
+}
+
+func (config *Config) BuildTransfer(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
+	config.setCommonFields(transaction, TRANSACTION_TYPES.Transfer)
 
 	transaction.Asset = &TransactionAsset{}
 
-	return buildSignedTransaction(transaction, passphrase, secondPassphrase)
+	return config.buildSignedTransaction(transaction, passphrase, secondPassphrase)
 }
 
 /** Set all fields and sign a multi signature TransactionTypes.Transfer transaction.
@@ -95,11 +103,15 @@ func BuildTransfer(transaction *Transaction, passphrase string, secondPassphrase
  *   Timestamp - optional, if 0, then it will be set to the present time
  *   VendorField - optional */
 func BuildTransferMultiSignature(transaction *Transaction, signerIndex int, passphrase string) *Transaction {
-	setCommonFields(transaction, TRANSACTION_TYPES.Transfer)
+	return DefaultConfig.BuildTransferMultiSignature(transaction, signerIndex, passphrase)
+}
+
+func (config *Config) BuildTransferMultiSignature(transaction *Transaction, signerIndex int, passphrase string) *Transaction {
+	config.setCommonFields(transaction, TRANSACTION_TYPES.Transfer)
 
 	transaction.Asset = &TransactionAsset{}
 
-	return buildMultiSignedTransaction(transaction, signerIndex, passphrase)
+	return config.buildMultiSignedTransaction(transaction, signerIndex, passphrase)
 }
 
 /** Set all fields and sign a TransactionTypes.SecondSignatureRegistration transaction.
@@ -111,9 +123,13 @@ func BuildTransferMultiSignature(transaction *Transaction, signerIndex int, pass
  *   Timestamp - optional, if 0, then it will be set to the present time
  *   VendorField - optional */
 func BuildSecondSignatureRegistration(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
-	setCommonFields(transaction, TRANSACTION_TYPES.SecondSignatureRegistration)
+	return DefaultConfig.BuildSecondSignatureRegistration(transaction, passphrase, secondPassphrase)
+}
 
-	secondPublicKey, _ := PublicKeyFromPassphrase(secondPassphrase)
+func (config *Config) BuildSecondSignatureRegistration(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
+	config.setCommonFields(transaction, TRANSACTION_TYPES.SecondSignatureRegistration)
+
+	secondPublicKey, _ := config.PublicKeyFromPassphrase(secondPassphrase)
 
 	transaction.Amount = 0
 	transaction.Asset = &TransactionAsset{
@@ -122,7 +138,7 @@ func BuildSecondSignatureRegistration(transaction *Transaction, passphrase strin
 		},
 	}
 
-	return buildSignedTransaction(transaction, passphrase, secondPassphrase)
+	return config.buildSignedTransaction(transaction, passphrase, secondPassphrase)
 }
 
 /** Set all fields and sign a TransactionTypes.DelegateRegistration transaction.
@@ -135,9 +151,13 @@ func BuildSecondSignatureRegistration(transaction *Transaction, passphrase strin
  *   Timestamp - optional, if 0, then it will be set to the present time
  *   VendorField - optional */
 func BuildDelegateRegistration(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
-	setCommonFields(transaction, TRANSACTION_TYPES.DelegateRegistration)
+	return DefaultConfig.BuildDelegateRegistration(transaction, passphrase, secondPassphrase)
+}
+
+func (config *Config) BuildDelegateRegistration(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
+	config.setCommonFields(transaction, TRANSACTION_TYPES.DelegateRegistration)
 
-	return buildSignedTransaction(transaction, passphrase, secondPassphrase)
+	return config.buildSignedTransaction(transaction, passphrase, secondPassphrase)
 }
 
 /** Set all fields and sign a TransactionTypes.Vote transaction.
@@ -150,11 +170,15 @@ func BuildDelegateRegistration(transaction *Transaction, passphrase string, seco
  *   Timestamp - optional, if 0, then it will be set to the present time
  *   VendorField - optional */
 func BuildVote(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
-	setCommonFields(transaction, TRANSACTION_TYPES.Vote)
+	return DefaultConfig.BuildVote(transaction, passphrase, secondPassphrase)
+}
+
+func (config *Config) BuildVote(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
+	config.setCommonFields(transaction, TRANSACTION_TYPES.Vote)
 
-	transaction.RecipientId, _ = AddressFromPassphrase(passphrase)
+	transaction.RecipientId, _ = config.AddressFromPassphrase(passphrase)
 
-	return buildSignedTransaction(transaction, passphrase, secondPassphrase)
+	return config.buildSignedTransaction(transaction, passphrase, secondPassphrase)
 }
 
 /** Set all fields and sign a TransactionTypes.MultiSignatureRegistration transaction.
@@ -167,9 +191,13 @@ func BuildVote(transaction *Transaction, passphrase string, secondPassphrase str
  *   Timestamp - optional, if 0, then it will be set to the present time
  *   VendorField - optional */
 func BuildMultiSignatureRegistration(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
-	setCommonFields(transaction, TRANSACTION_TYPES.MultiSignatureRegistration)
+	return DefaultConfig.BuildMultiSignatureRegistration(transaction, passphrase, secondPassphrase)
+}
 
-	return buildSignedTransaction(transaction, passphrase, secondPassphrase)
+func (config *Config) BuildMultiSignatureRegistration(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
+	config.setCommonFields(transaction, TRANSACTION_TYPES.MultiSignatureRegistration)
+
+	return config.buildSignedTransaction(transaction, passphrase, secondPassphrase)
 }
 
 /** Set all fields and sign a TransactionTypes.Ipfs transaction.
@@ -182,9 +210,13 @@ func BuildMultiSignatureRegistration(transaction *Transaction, passphrase string
  *   Timestamp - optional, if 0, then it will be set to the present time
  *   VendorField - optional */
 func BuildIpfs(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
-	setCommonFields(transaction, TRANSACTION_TYPES.Ipfs)
+	return DefaultConfig.BuildIpfs(transaction, passphrase, secondPassphrase)
+}
 
-	return buildSignedTransaction(transaction, passphrase, secondPassphrase)
+func (config *Config) BuildIpfs(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
+	config.setCommonFields(transaction, TRANSACTION_TYPES.Ipfs)
+
+	return config.buildSignedTransaction(transaction, passphrase, secondPassphrase)
 }
 
 /** Set all fields and sign a TransactionTypes.MultiPayment transaction.
@@ -197,9 +229,13 @@ func BuildIpfs(transaction *Transaction, passphrase string, secondPassphrase str
  *   Timestamp - optional, if 0, then it will be set to the present time
  *   VendorField - optional */
 func BuildMultiPayment(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
-	setCommonFields(transaction, TRANSACTION_TYPES.MultiPayment)
+	return DefaultConfig.BuildMultiPayment(transaction, passphrase, secondPassphrase)
+}
 
-	return buildSignedTransaction(transaction, passphrase, secondPassphrase)
+func (config *Config) BuildMultiPayment(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
+	config.setCommonFields(transaction, TRANSACTION_TYPES.MultiPayment)
+
+	return config.buildSignedTransaction(transaction, passphrase, secondPassphrase)
 }
 
 /** Set all fields and sign a TransactionTypes.DelegateResignation transaction.
@@ -211,9 +247,13 @@ func BuildMultiPayment(transaction *Transaction, passphrase string, secondPassph
  *   Timestamp - optional, if 0, then it will be set to the present time
  *   VendorField - optional */
 func BuildDelegateResignation(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
-	setCommonFields(transaction, TRANSACTION_TYPES.DelegateResignation)
+	return DefaultConfig.BuildDelegateResignation(transaction, passphrase, secondPassphrase)
+}
+
+func (config *Config) BuildDelegateResignation(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
+	config.setCommonFields(transaction, TRANSACTION_TYPES.DelegateResignation)
 
-	return buildSignedTransaction(transaction, passphrase, secondPassphrase)
+	return config.buildSignedTransaction(transaction, passphrase, secondPassphrase)
 }
 
 /** Set all fields and sign a TransactionTypes.HtlcLock transaction.
@@ -226,9 +266,13 @@ func BuildDelegateResignation(transaction *Transaction, passphrase string, secon
  *   Timestamp - optional, if 0, then it will be set to the present time
  *   VendorField - optional */
 func BuildHtlcLock(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
-	setCommonFields(transaction, TRANSACTION_TYPES.HtlcLock)
+	return DefaultConfig.BuildHtlcLock(transaction, passphrase, secondPassphrase)
+}
+
+func (config *Config) BuildHtlcLock(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
+	config.setCommonFields(transaction, TRANSACTION_TYPES.HtlcLock)
 
-	return buildSignedTransaction(transaction, passphrase, secondPassphrase)
+	return config.buildSignedTransaction(transaction, passphrase, secondPassphrase)
 }
 
 /** Set all fields and sign a TransactionTypes.HtlcClaim transaction.
@@ -241,9 +285,13 @@ func BuildHtlcLock(transaction *Transaction, passphrase string, secondPassphrase
  *   Timestamp - optional, if 0, then it will be set to the present time
  *   VendorField - optional */
 func BuildHtlcClaim(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
-	setCommonFields(transaction, TRANSACTION_TYPES.HtlcClaim)
+	return DefaultConfig.BuildHtlcClaim(transaction, passphrase, secondPassphrase)
+}
 
-	return buildSignedTransaction(transaction, passphrase, secondPassphrase)
+func (config *Config) BuildHtlcClaim(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
+	config.setCommonFields(transaction, TRANSACTION_TYPES.HtlcClaim)
+
+	return config.buildSignedTransaction(transaction, passphrase, secondPassphrase)
 }
 
 /** Set all fields and sign a TransactionTypes.HtlcRefund transaction.
@@ -256,7 +304,11 @@ func BuildHtlcClaim(transaction *Transaction, passphrase string, secondPassphras
  *   Timestamp - optional, if 0, then it will be set to the present time
  *   VendorField - optional */
 func BuildHtlcRefund(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
-	setCommonFields(transaction, TRANSACTION_TYPES.HtlcRefund)
+	return DefaultConfig.BuildHtlcRefund(transaction, passphrase, secondPassphrase)
+}
+
+func (config *Config) BuildHtlcRefund(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
+	config.setCommonFields(transaction, TRANSACTION_TYPES.HtlcRefund)
 
-	return buildSignedTransaction(transaction, passphrase, secondPassphrase)
+	return config.buildSignedTransaction(transaction, passphrase, secondPassphrase)
 }
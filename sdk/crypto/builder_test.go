@@ -284,7 +284,7 @@ func TestBuild(t *testing.T) {
 			"ECDSA": SIGNATURE_TYPE_ECDSA,
 			"Schnorr": SIGNATURE_TYPE_SCHNORR,
 		} {
-			CONFIG_SIGNATURE_TYPE = signatureType
+			DefaultConfig.SignatureType = signatureType
 
 			test := func (t *testing.T) {
 				transaction := buildTransaction(t)
@@ -300,7 +300,7 @@ func TestBuild(t *testing.T) {
 
 	// Test multisignature transfer separately
 
-	CONFIG_SIGNATURE_TYPE = SIGNATURE_TYPE_SCHNORR
+	DefaultConfig.SignatureType = SIGNATURE_TYPE_SCHNORR
 
 	test := func (t *testing.T) {
 		transaction := transferMultiSignature(t)
@@ -7,40 +7,72 @@
 
 package crypto
 
-var (
-	CONFIG_NETWORK = &Network{}
-	CONFIG_FEES    = []FlexToshi{
-		TRANSACTION_FEES.Transfer,
-		TRANSACTION_FEES.SecondSignatureRegistration,
-		TRANSACTION_FEES.DelegateRegistration,
-		TRANSACTION_FEES.Vote,
-		TRANSACTION_FEES.MultiSignatureRegistration,
-		TRANSACTION_FEES.Ipfs,
-		TRANSACTION_FEES.MultiPayment,
-		TRANSACTION_FEES.DelegateResignation,
-		TRANSACTION_FEES.HtlcLock,
-		TRANSACTION_FEES.HtlcClaim,
-		TRANSACTION_FEES.HtlcRefund,
+// Config holds the network, the static fees and the signature type used by
+// the builders, signers, serializer and key helpers. Several configs can be
+// used side by side in one process, e.g. one per ARK based chain.
+type Config struct {
+	Network       *Network
+	Fees          []FlexToshi
+	SignatureType int
+}
+
+// The config used by the package level functions.
+var DefaultConfig = NewConfig(nil)
+
+// Create a config for the given network with the default fees and signature
+// type. A nil network falls back to mainnet.
+func NewConfig(network *Network) *Config {
+	return &Config{
+		Network: network,
+		Fees: []FlexToshi{
+			TRANSACTION_FEES.Transfer,
+			TRANSACTION_FEES.SecondSignatureRegistration,
+			TRANSACTION_FEES.DelegateRegistration,
+			TRANSACTION_FEES.Vote,
+			TRANSACTION_FEES.MultiSignatureRegistration,
+			TRANSACTION_FEES.Ipfs,
+			TRANSACTION_FEES.MultiPayment,
+			TRANSACTION_FEES.DelegateResignation,
+			TRANSACTION_FEES.HtlcLock,
+			TRANSACTION_FEES.HtlcClaim,
+			TRANSACTION_FEES.HtlcRefund,
+		},
+		SignatureType: SIGNATURE_TYPE_SCHNORR,
 	}
-	CONFIG_SIGNATURE_TYPE = SIGNATURE_TYPE_SCHNORR
-)
+}
 
-func GetNetwork() *Network {
-	if CONFIG_NETWORK.Version == 0 {
+func (config *Config) GetNetwork() *Network {
+	if config.Network == nil || config.Network.Version == 0 {
 		return NETWORKS_MAINNET
 	}
 
-	return CONFIG_NETWORK
+	return config.Network
+}
+
+func (config *Config) SetNetwork(network *Network) {
+	config.Network = network
+}
+
+func (config *Config) GetFee(transactionType uint16) FlexToshi {
+	return config.Fees[transactionType]
+}
+
+func (config *Config) SetFee(transactionType uint16, value FlexToshi) {
+	config.Fees[transactionType] = value
+}
+
+func GetNetwork() *Network {
+	return DefaultConfig.GetNetwork()
 }
 
 func SetNetwork(network *Network) {
-	CONFIG_NETWORK = network
+	DefaultConfig.SetNetwork(network)
 }
 
 func GetFee(transactionType uint16) FlexToshi {
-	return CONFIG_FEES[transactionType]
+	return DefaultConfig.GetFee(transactionType)
 }
 
 func SetFee(transactionType uint16, value FlexToshi) {
-	CONFIG_FEES[transactionType] = value
+	DefaultConfig.SetFee(transactionType, value)
 }
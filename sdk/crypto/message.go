@@ -15,7 +15,11 @@ import (
 )
 
 func SignMessage(message string, passphrase string) (*Message, error) {
-	privateKey, err := PrivateKeyFromPassphrase(passphrase)
+	return DefaultConfig.SignMessage(message, passphrase)
+}
+
+func (config *Config) SignMessage(message string, passphrase string) (*Message, error) {
+	privateKey, err := config.PrivateKeyFromPassphrase(passphrase)
 
 	if err != nil {
 		return nil, err
@@ -28,7 +32,7 @@ func SignMessage(message string, passphrase string) (*Message, error) {
 		return nil, err
 	}
 
-	signature, err := privateKey.Sign(hash.Sum(nil))
+	signature, err := config.Sign(privateKey, hash.Sum(nil))
 
 	if err != nil {
 		return nil, err
@@ -14,7 +14,7 @@ import (
 )
 
 func TestSignMessage(t *testing.T) {
-	CONFIG_SIGNATURE_TYPE = SIGNATURE_TYPE_ECDSA
+	DefaultConfig.SignatureType = SIGNATURE_TYPE_ECDSA
 
 	fixture := GetMessageFixture()
 
@@ -14,15 +14,35 @@ var (
 		Epoch:   time.Date(2017, 3, 21, 13, 00, 0, 0, time.UTC),
 		Version: 23,
 		Wif:     170,
+		Nethash: "6e84d08bd299ed97c212c886c98a57e36545c8f5d645ca7eeae63a8bd62d8988",
 	}
 	NETWORKS_DEVNET = &Network{
 		Epoch:   time.Date(2017, 3, 21, 13, 00, 0, 0, time.UTC),
 		Version: 30,
 		Wif:     170,
+		Nethash: "2a44f340d76ffc3df204c5f38cd355b7496c9065a1ade2ef92071436bd72e867",
 	}
 	NETWORKS_TESTNET = &Network{
 		Epoch:   time.Date(2017, 3, 21, 13, 00, 0, 0, time.UTC),
 		Version: 23,
 		Wif:     186,
 	}
+	NETWORKS = map[string]*Network{
+		"mainnet": NETWORKS_MAINNET,
+		"devnet":  NETWORKS_DEVNET,
+		"testnet": NETWORKS_TESTNET,
+	}
 )
+
+// Get a copy of the predefined network with the given name, so that callers
+// can adjust it without touching the shared definition.
+func NetworkByName(name string) (*Network, bool) {
+	network, ok := NETWORKS[name]
+	if !ok {
+		return nil, false
+	}
+
+	copied := *network
+
+	return &copied, true
+}
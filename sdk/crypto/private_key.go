@@ -18,6 +18,10 @@ import (
 )
 
 func PrivateKeyFromPassphrase(passphrase string) (*PrivateKey, error) {
+	return DefaultConfig.PrivateKeyFromPassphrase(passphrase)
+}
+
+func (config *Config) PrivateKeyFromPassphrase(passphrase string) (*PrivateKey, error) {
 	hash := sha256.New()
 	_, err := hash.Write([]byte(passphrase))
 
@@ -25,14 +29,22 @@ func PrivateKeyFromPassphrase(passphrase string) (*PrivateKey, error) {
 		return nil, err
 	}
 
-	return PrivateKeyFromBytes(hash.Sum(nil)), nil
+	return config.PrivateKeyFromBytes(hash.Sum(nil)), nil
 }
 
 func PrivateKeyFromHex(privateKeyHex string) (*PrivateKey, error) {
-	return PrivateKeyFromBytes(HexDecode(privateKeyHex)), nil
+	return DefaultConfig.PrivateKeyFromHex(privateKeyHex)
+}
+
+func (config *Config) PrivateKeyFromHex(privateKeyHex string) (*PrivateKey, error) {
+	return config.PrivateKeyFromBytes(HexDecode(privateKeyHex)), nil
 }
 
 func PrivateKeyFromBytes(bytes []byte) *PrivateKey {
+	return DefaultConfig.PrivateKeyFromBytes(bytes)
+}
+
+func (config *Config) PrivateKeyFromBytes(bytes []byte) *PrivateKey {
 	privateKey, publicKey := btcec.PrivKeyFromBytes(btcec.S256(), bytes)
 
 	return &PrivateKey{
@@ -40,7 +52,7 @@ func PrivateKeyFromBytes(bytes []byte) *PrivateKey {
 		PublicKey: &PublicKey{
 			PublicKey:    publicKey,
 			isCompressed: true,
-			Network:      GetNetwork(),
+			Network:      config.GetNetwork(),
 		},
 	}
 }
@@ -101,12 +113,17 @@ func (privateKey *PrivateKey) SignSchnorr(hash []byte) ([]byte, error) {
 }
 
 func (privateKey *PrivateKey) Sign(hash []byte) ([]byte, error) {
-	switch CONFIG_SIGNATURE_TYPE {
+	return DefaultConfig.Sign(privateKey, hash)
+}
+
+// Sign the hash with the signature type of the config.
+func (config *Config) Sign(privateKey *PrivateKey, hash []byte) ([]byte, error) {
+	switch config.SignatureType {
 	case SIGNATURE_TYPE_ECDSA:
 		return privateKey.SignECDSA(hash)
 	case SIGNATURE_TYPE_SCHNORR:
 		return privateKey.SignSchnorr(hash)
 	}
 
-	return nil, fmt.Errorf("Sign: unknown signature type configured: %d", CONFIG_SIGNATURE_TYPE)
+	return nil, fmt.Errorf("Sign: unknown signature type configured: %d", config.SignatureType)
 }
@@ -17,7 +17,11 @@ import (
 )
 
 func PublicKeyFromPassphrase(passphrase string) (*PublicKey, error) {
-	privateKey, err := PrivateKeyFromPassphrase(passphrase)
+	return DefaultConfig.PublicKeyFromPassphrase(passphrase)
+}
+
+func (config *Config) PublicKeyFromPassphrase(passphrase string) (*PublicKey, error) {
+	privateKey, err := config.PrivateKeyFromPassphrase(passphrase)
 
 	if err != nil {
 		return nil, err
@@ -27,7 +31,11 @@ func PublicKeyFromPassphrase(passphrase string) (*PublicKey, error) {
 }
 
 func PublicKeyFromHex(publicKeyHex string) (*PublicKey, error) {
-	publicKey, err := PublicKeyFromBytes(HexDecode(publicKeyHex))
+	return DefaultConfig.PublicKeyFromHex(publicKeyHex)
+}
+
+func (config *Config) PublicKeyFromHex(publicKeyHex string) (*PublicKey, error) {
+	publicKey, err := config.PublicKeyFromBytes(HexDecode(publicKeyHex))
 
 	if err != nil {
 		return nil, err
@@ -37,6 +45,10 @@ func PublicKeyFromHex(publicKeyHex string) (*PublicKey, error) {
 }
 
 func PublicKeyFromBytes(bytes []byte) (*PublicKey, error) {
+	return DefaultConfig.PublicKeyFromBytes(bytes)
+}
+
+func (config *Config) PublicKeyFromBytes(bytes []byte) (*PublicKey, error) {
 	publicKey, err := btcec.ParsePubKey(bytes, btcec.S256())
 
 	if err != nil {
@@ -52,7 +64,7 @@ func PublicKeyFromBytes(bytes []byte) (*PublicKey, error) {
 	return &PublicKey{
 		PublicKey:    publicKey,
 		isCompressed: isCompressed,
-		Network:      GetNetwork(),
+		Network:      config.GetNetwork(),
 	}, nil
 }
 
@@ -28,9 +28,15 @@ func writeNumberAsByte(ser *bytes.Buffer, num interface{}, name string) {
 }
 
 func (transaction *Transaction) Serialize(includeSignature bool, includeSecondSignature bool, includeMultiSignatures bool) []byte {
+	return DefaultConfig.Serialize(transaction, includeSignature, includeSecondSignature, includeMultiSignatures)
+}
+
+// Serialize the transaction, falling back to the network of the config when
+// the transaction has no network set.
+func (config *Config) Serialize(transaction *Transaction, includeSignature bool, includeSecondSignature bool, includeMultiSignatures bool) []byte {
 	ser := new(bytes.Buffer)
 
-    transaction.serializeHeader(ser)
+    transaction.serializeHeader(ser, config.GetNetwork())
     transaction.serializeVendorField(ser)
     transaction.serializeTypeSpecific(ser)
     transaction.serializeSignatures(ser, includeSignature, includeSecondSignature, includeMultiSignatures)
@@ -38,7 +44,7 @@ func (transaction *Transaction) Serialize(includeSignature bool, includeSecondSi
     return ser.Bytes()
 }
 
-func (transaction *Transaction) serializeHeader(ser *bytes.Buffer) {
+func (transaction *Transaction) serializeHeader(ser *bytes.Buffer, network *Network) {
 	ser.WriteByte(uint8(0xFF))
 
 	if transaction.Version == 2 {
@@ -48,7 +54,7 @@ func (transaction *Transaction) serializeHeader(ser *bytes.Buffer) {
 	}
 
 	if transaction.Network == 0 {
-		ser.WriteByte(GetNetwork().Version)
+		ser.WriteByte(network.Version)
 	} else {
 		ser.WriteByte(transaction.Network)
 	}
@@ -10,8 +10,12 @@ package crypto
 import "time"
 
 func GetTime() int32 {
+	return DefaultConfig.GetTime()
+}
+
+func (config *Config) GetTime() int32 {
 	now := time.Now()
-	diff := now.Sub(GetNetwork().Epoch)
+	diff := now.Sub(config.GetNetwork().Epoch)
 
 	return int32(diff.Seconds())
 }
@@ -21,6 +21,7 @@ type Network struct {
 	Epoch   time.Time
 	Version byte
 	Wif     byte
+	Nethash string
 }
 
 type PrivateKey struct {
@@ -11,34 +11,45 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"log"
 
 	"github.com/fatih/structs"
 )
 
 func (transaction *Transaction) GetId() string {
-	return fmt.Sprintf("%x", sha256.Sum256(transaction.Serialize(true, true, true)))
+	return DefaultConfig.GetId(transaction)
+}
+
+func (config *Config) GetId(transaction *Transaction) string {
+	return fmt.Sprintf("%x", sha256.Sum256(config.Serialize(transaction, true, true, true)))
 }
 
 func (transaction *Transaction) Sign(passphrase string) {
-	privateKey, _ := PrivateKeyFromPassphrase(passphrase)
+	DefaultConfig.SignTransaction(transaction, passphrase)
+}
+
+func (config *Config) SignTransaction(transaction *Transaction, passphrase string) {
+	privateKey, _ := config.PrivateKeyFromPassphrase(passphrase)
 
 	transaction.SenderPublicKey = HexEncode(privateKey.PublicKey.Serialize())
 
-	hash := sha256.Sum256(transaction.Serialize(false, false, false))
+	hash := sha256.Sum256(config.Serialize(transaction, false, false, false))
 
-	signature, err := privateKey.Sign(hash[:])
+	signature, err := config.Sign(privateKey, hash[:])
 	if err == nil {
 		transaction.Signature = HexEncode(signature)
 	}
 }
 
 func (transaction *Transaction) SignMulti(signerIndex int, passphrase string) {
-	privateKey, _ := PrivateKeyFromPassphrase(passphrase)
+	DefaultConfig.SignMultiTransaction(transaction, signerIndex, passphrase)
+}
 
-	hash := sha256.Sum256(transaction.Serialize(false, false, false))
+func (config *Config) SignMultiTransaction(transaction *Transaction, signerIndex int, passphrase string) {
+	privateKey, _ := config.PrivateKeyFromPassphrase(passphrase)
+
+	hash := sha256.Sum256(config.Serialize(transaction, false, false, false))
 
-	signature, err := privateKey.Sign(hash[:])
+	signature, err := config.Sign(privateKey, hash[:])
 	if err == nil {
 		var signatureWithIndex []byte
 		signatureWithIndex = append(signatureWithIndex, byte(signerIndex))
@@ -48,11 +59,15 @@ func (transaction *Transaction) SignMulti(signerIndex int, passphrase string) {
 }
 
 func (transaction *Transaction) SecondSign(passphrase string) {
-	privateKey, _ := PrivateKeyFromPassphrase(passphrase)
+	DefaultConfig.SecondSignTransaction(transaction, passphrase)
+}
 
-	hash := sha256.Sum256(transaction.Serialize(true, false, false))
+func (config *Config) SecondSignTransaction(transaction *Transaction, passphrase string) {
+	privateKey, _ := config.PrivateKeyFromPassphrase(passphrase)
+
+	hash := sha256.Sum256(config.Serialize(transaction, true, false, false))
 
-	signature, err := privateKey.Sign(hash[:])
+	signature, err := config.Sign(privateKey, hash[:])
 	if err == nil {
 		transaction.SecondSignature = HexEncode(signature)
 	}
@@ -174,8 +189,8 @@ func (transaction *Transaction) ParseSignaturesECDSA(signatures []byte) *Transac
 	}
 
 	if o != signaturesLen {
-		log.Fatal("All signatures parsed, but ", signaturesLen - o,
-			" bytes remain in the buffer: ", HexEncode(signatures))
+		panic(fmt.Sprint("All signatures parsed, but ", signaturesLen - o,
+			" bytes remain in the buffer: ", HexEncode(signatures)))
 	}
 
 	return transaction
@@ -211,7 +226,7 @@ func (transaction *Transaction) ParseSignaturesSchnorr(signatures []byte) *Trans
 	}
 
 	if (signaturesLen - o) % 65 != 0 {
-		log.Fatalf("Cannot parse Schnorr signatures: remaining bytes not multiple of 65: %d", signaturesLen - o)
+		panic(fmt.Sprintf("Cannot parse Schnorr signatures: remaining bytes not multiple of 65: %d", signaturesLen - o))
 	}
 
 	count := (signaturesLen - o) / 65
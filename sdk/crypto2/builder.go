@@ -7,6 +7,8 @@
 
 package crypto2
 
+import "time"
+
 func buildSignedTransaction(transaction *Transaction, passphrase string, secondPassphrase string) *Transaction {
 	transaction.Timestamp = GetTime()
 	transaction.Sign(passphrase)
@@ -20,18 +22,18 @@ func buildSignedTransaction(transaction *Transaction, passphrase string, secondP
 	return transaction
 }
 
-func BuildTransferMySelf(recipient string, amount FlexToshi, senderpk string, senderid string) *Transaction {
+func BuildTransferMySelf(recipient string, amount FlexToshi, senderpk string, senderid string, network *Network) *Transaction {
 	transaction := &Transaction{
 		Type:            TRANSACTION_TYPES.Transfer,
 		Fee:             GetFee(TRANSACTION_TYPES.Transfer),
 		RecipientId:     recipient,
 		Amount:          amount,
 		SenderPublicKey: senderpk,
-		Network:         23,
+		Network:         network.Version,
 		Asset:           &TransactionAsset{},
 		SenderId:        senderid,
 	}
-	transaction.Timestamp = GetTime()
+	transaction.Timestamp = int32(time.Now().Sub(network.Epoch).Seconds())
 
 	return transaction
 }